@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+)
+
+// runAPIKeysCommand handles "apikeys create <name> <scope>...", "apikeys list"
+// and "apikeys revoke <id>", e.g. to create the first admin key
+func runAPIKeysCommand(database db.DatabaseHelper, args []string) {
+	keys := dao.NewAPIKeysDao(database)
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "create":
+		if len(args) < 3 {
+			log.Fatal("usage: apikeys create <name> <scope>...")
+		}
+		for _, scope := range args[2:] {
+			if !auth.ValidScope(scope) {
+				log.Fatalf("unknown scope %q, use one of %v", scope, auth.SCOPES)
+			}
+		}
+		key, plaintext, err := auth.NewAPIKey(args[1], args[2:])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		if err := keys.Create(context.Background(), key); err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Info("Created the api key ", key.ID.Hex(), ", it won't be shown again")
+		fmt.Println(plaintext)
+	case "list":
+		all, err := keys.FindAll(context.Background())
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(all)
+	case "revoke":
+		if len(args) < 2 {
+			log.Fatal("usage: apikeys revoke <id>")
+		}
+		if err := keys.Revoke(context.Background(), args[1]); err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Info("Revoked the api key ", args[1])
+	default:
+		log.Fatalf("unknown apikeys command %q, use create, list or revoke", action)
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+)
+
+const (
+	API_KEY_HEADER = "X-API-Key"
+	// API_KEY_PREFIX starts every key so they are easy to tell apart from
+	// other secrets, e.g. by secret scanners
+	API_KEY_PREFIX = "swapi_"
+	// API_KEY_DISPLAY_LENGTH is how much of a key is stored in clear to
+	// identify it
+	API_KEY_DISPLAY_LENGTH = len(API_KEY_PREFIX) + 6
+	API_KEY_BYTES          = 32
+)
+
+// GenerateKey returns a new random key, its display prefix and its hash
+func GenerateKey() (key string, prefix string, hash string, err error) {
+	random := make([]byte, API_KEY_BYTES)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", err
+	}
+	key = API_KEY_PREFIX + base64.RawURLEncoding.EncodeToString(random)
+	return key, key[:API_KEY_DISPLAY_LENGTH], HashKey(key), nil
+}
+
+// NewAPIKey returns a new key for name with the scopes and the plaintext key,
+// which must be shown to its owner now since only its hash is stored
+func NewAPIKey(name string, scopes []string) (*models.APIKey, string, error) {
+	key, prefix, hash, err := GenerateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	return &models.APIKey{
+		ID:        db.ObjectID().NewObjectID(),
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}, key, nil
+}
+
+// HashKey returns the sha256 of a key, the keys are random enough to not
+// need a slow hash
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyAuthenticator authenticates the keys sent in X-API-Key or as a
+// bearer token
+type apiKeyAuthenticator struct {
+	keys dao.APIKeysDAO
+}
+
+func NewAPIKeyAuthenticator(keys dao.APIKeysDAO) Authenticator {
+	return &apiKeyAuthenticator{keys: keys}
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(API_KEY_HEADER)
+	if key == "" {
+		key = BearerToken(r)
+	}
+	if !strings.HasPrefix(key, API_KEY_PREFIX) {
+		return nil, nil
+	}
+	found, err := a.keys.FindByHash(r.Context(), HashKey(key))
+	if err != nil {
+		if err.Error() == dao.NOT_FOUND_ERROR_MESSAGE {
+			logging.FromContext(r.Context()).Info("Unknown api key")
+			return nil, errors.New(UNAUTHORIZED_ERROR_MESSAGE)
+		}
+		return nil, err
+	}
+	if found.RevokedAt != nil {
+		logging.FromContext(r.Context()).WithField("api_key", found.Prefix).Info("Revoked api key")
+		return nil, errors.New(UNAUTHORIZED_ERROR_MESSAGE)
+	}
+	return &Principal{ID: "api_key:" + found.ID.Hex(), Name: found.Name, Scopes: found.Scopes}, nil
+}
+
+// BearerToken returns the token of the Authorization header, if any
+func BearerToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(authorization[len("Bearer "):])
+	}
+	return ""
+}
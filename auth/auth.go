@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SCOPE_PLANETS_READ  = "planets:read"
+	SCOPE_PLANETS_WRITE = "planets:write"
+	SCOPE_FILMS_READ    = "films:read"
+	SCOPE_FILMS_WRITE   = "films:write"
+	SCOPE_PEOPLE_READ   = "people:read"
+	SCOPE_PEOPLE_WRITE  = "people:write"
+	SCOPE_ADMIN         = "admin"
+)
+
+const (
+	UNAUTHORIZED_ERROR_MESSAGE = "Unauthorized"
+	FORBIDDEN_ERROR_MESSAGE    = "Forbidden"
+	UNAVAILABLE_ERROR_MESSAGE  = "Authentication unavailable"
+	// UNAVAILABLE_RETRY_AFTER is sent when the credentials can't be verified yet
+	UNAVAILABLE_RETRY_AFTER = 5 * time.Second
+)
+
+// SCOPES are the scopes a principal may be granted
+var SCOPES = []string{
+	SCOPE_PLANETS_READ, SCOPE_PLANETS_WRITE,
+	SCOPE_FILMS_READ, SCOPE_FILMS_WRITE,
+	SCOPE_PEOPLE_READ, SCOPE_PEOPLE_WRITE,
+	SCOPE_ADMIN,
+}
+
+// ANONYMOUS is the principal of every request while the authentication is
+// disabled, it has every scope but SCOPE_ADMIN: the keys it could create would
+// keep working once the authentication is enabled, the first admin key is
+// created from the command line instead
+var ANONYMOUS = Principal{Name: "anonymous", Scopes: []string{
+	SCOPE_PLANETS_READ, SCOPE_PLANETS_WRITE,
+	SCOPE_FILMS_READ, SCOPE_FILMS_WRITE,
+	SCOPE_PEOPLE_READ, SCOPE_PEOPLE_WRITE,
+}}
+
+// Principal is who sent a request and what it may do. ID identifies its
+// credentials, it is empty for ANONYMOUS, and Roles are the roles of a JWT
+// granting the Scopes.
+type Principal struct {
+	ID     string
+	Name   string
+	Roles  []string
+	Scopes []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator returns the principal of the credentials of a request, nil
+// when the request doesn't have its kind of credentials, or an error with
+// UNAUTHORIZED_ERROR_MESSAGE when they are invalid
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// ValidScope tells whether the scope is one of SCOPES
+func ValidScope(scope string) bool {
+	for _, s := range SCOPES {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Require answers 403 unless the principal of the request has the scope, and
+// 401 when the request wasn't authenticated
+func Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := FromContext(r.Context())
+		if !ok {
+			RespondWithError(w, errors.New(UNAUTHORIZED_ERROR_MESSAGE))
+			return
+		}
+		if !principal.HasScope(scope) {
+			RespondWithError(w, errors.New(FORBIDDEN_ERROR_MESSAGE))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RespondWithError answers 401 or 403 for the authentication errors, 503
+// when the jwks can't be fetched and 500 for the others, e.g. when the keys
+// can't be read
+func RespondWithError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	message := http.StatusText(code)
+	var fetch *FetchError
+	if errors.As(err, &fetch) {
+		code, message = http.StatusServiceUnavailable, UNAVAILABLE_ERROR_MESSAGE
+		w.Header().Set("Retry-After", strconv.Itoa(int(UNAVAILABLE_RETRY_AFTER.Seconds())))
+	}
+	switch err.Error() {
+	case UNAUTHORIZED_ERROR_MESSAGE:
+		code, message = http.StatusUnauthorized, err.Error()
+		w.Header().Set("WWW-Authenticate", `Bearer realm="star-wars-api"`)
+	case FORBIDDEN_ERROR_MESSAGE:
+		code, message = http.StatusForbidden, err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// JWKS_TIMEOUT bounds the requests fetching the keys
+const JWKS_TIMEOUT = 10 * time.Second
+
+var errUnknownKey = errors.New("unknown key id")
+
+// FetchError is returned when the keys are needed but can't be fetched
+type FetchError struct {
+	err error
+}
+
+func (e *FetchError) Error() string {
+	return "fetching the jwks: " + e.err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.err
+}
+
+// publicKey is a signature key of the set, Algorithm is empty when the key
+// doesn't restrict it
+type publicKey struct {
+	Key       crypto.PublicKey
+	Algorithm string
+}
+
+// JWKS caches the public keys of a JSON Web Key Set by key id. The keys are
+// fetched again when they are older than the ttl or when a token has an
+// unknown key id, which happens after the issuer rotated its keys; the
+// refreshes are at most once per minRefresh and the cached keys are kept
+// when they fail. A single request fetches the keys at a time, without
+// holding the lock: the cached keys are served meanwhile and only the
+// tokens of an unknown key id wait for it.
+type JWKS struct {
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+	mu         sync.Mutex
+	keys       map[string]publicKey
+	fetched    time.Time
+	attempted  time.Time
+	inflight   *jwksFetch
+}
+
+// jwksFetch is a fetch of the keys in flight, err is set once done is closed
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+func NewJWKS(url string, ttl time.Duration, minRefresh time.Duration) *JWKS {
+	return &JWKS{
+		url:        url,
+		client:     &http.Client{Timeout: JWKS_TIMEOUT},
+		ttl:        ttl,
+		minRefresh: minRefresh,
+		keys:       map[string]publicKey{},
+	}
+}
+
+// Key returns the key with the id, fetching the keys when needed
+func (j *JWKS) Key(kid string) (publicKey, error) {
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	if ok && time.Since(j.fetched) <= j.ttl {
+		j.mu.Unlock()
+		return key, nil
+	}
+	fetch := j.inflight
+	if fetch == nil && time.Since(j.attempted) >= j.minRefresh {
+		fetch = j.startFetch()
+	}
+	j.mu.Unlock()
+	if ok {
+		// the expired key is served while the keys are fetched again
+		return key, nil
+	}
+	if fetch == nil {
+		return publicKey{}, errUnknownKey
+	}
+	<-fetch.done
+	if fetch.err != nil {
+		return publicKey{}, fetch.err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if key, ok = j.keys[kid]; !ok {
+		return publicKey{}, errUnknownKey
+	}
+	return key, nil
+}
+
+// Refresh fetches the keys now, e.g. on startup
+func (j *JWKS) Refresh() error {
+	j.mu.Lock()
+	fetch := j.inflight
+	if fetch == nil {
+		fetch = j.startFetch()
+	}
+	j.mu.Unlock()
+	<-fetch.done
+	return fetch.err
+}
+
+// startFetch fetches the keys in the background, j.mu must be held
+func (j *JWKS) startFetch() *jwksFetch {
+	fetch := &jwksFetch{done: make(chan struct{})}
+	j.inflight, j.attempted = fetch, time.Now()
+	go func() {
+		keys, err := j.fetch()
+		j.mu.Lock()
+		if err == nil {
+			j.keys, j.fetched = keys, time.Now()
+		} else {
+			log.WithError(err).Warn("There was an error fetching the jwks, using the cached keys")
+		}
+		fetch.err = err
+		j.inflight = nil
+		j.mu.Unlock()
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+func (j *JWKS) fetch() (map[string]publicKey, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, &FetchError{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{err: fmt.Errorf("%s answered %d", j.url, resp.StatusCode)}
+	}
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, &FetchError{err: err}
+	}
+	keys := map[string]publicKey{}
+	for _, raw := range set.Keys {
+		kid, key, err := parseJWK(raw)
+		if err != nil {
+			log.WithError(err).WithField("kid", kid).Warn("Skipped a key of the jwks")
+			continue
+		}
+		if key.Key != nil {
+			keys[kid] = key
+		}
+	}
+	log.WithField("keys", len(keys)).Debug("Fetched the jwks")
+	return keys, nil
+}
+
+// parseJWK returns the id and the public key of a JWK, or a nil key when it
+// isn't an RSA or P-256 signature key
+func parseJWK(raw json.RawMessage) (string, publicKey, error) {
+	var jwk struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return "", publicKey{}, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return jwk.Kid, publicKey{}, nil
+	}
+	switch {
+	case jwk.Kty == "RSA":
+		n, err := decodeInt(jwk.N)
+		if err != nil {
+			return jwk.Kid, publicKey{}, err
+		}
+		e, err := decodeInt(jwk.E)
+		if err != nil || !e.IsInt64() {
+			return jwk.Kid, publicKey{}, errors.New("invalid rsa exponent")
+		}
+		return jwk.Kid, publicKey{Key: &rsa.PublicKey{N: n, E: int(e.Int64())}, Algorithm: jwk.Alg}, nil
+	case jwk.Kty == "EC" && jwk.Crv == "P-256":
+		x, err := decodeInt(jwk.X)
+		if err != nil {
+			return jwk.Kid, publicKey{}, err
+		}
+		y, err := decodeInt(jwk.Y)
+		if err != nil {
+			return jwk.Kid, publicKey{}, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return jwk.Kid, publicKey{}, errors.New("the point isn't on the P-256 curve")
+		}
+		return jwk.Kid, publicKey{Key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, Algorithm: jwk.Alg}, nil
+	}
+	return jwk.Kid, publicKey{}, nil
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		return nil, errors.New("missing jwk parameter")
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/logging"
+)
+
+// jwtAuthenticator authenticates the bearer tokens signed by the keys of a
+// JWKS, the roles of the token grant the scopes of the config
+type jwtAuthenticator struct {
+	cnf    config.JWT
+	keys   *JWKS
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator fails when a role of the config grants a scope out of
+// SCOPES
+func NewJWTAuthenticator(cnf config.JWT, keys *JWKS) (Authenticator, error) {
+	for role, scopes := range cnf.Roles {
+		for _, scope := range scopes {
+			if !ValidScope(scope) {
+				return nil, fmt.Errorf("auth.jwt.roles.%s: unknown scope %q", role, scope)
+			}
+		}
+	}
+	return &jwtAuthenticator{
+		cnf:  cnf,
+		keys: keys,
+		// the claims are checked by validate, with the leeway
+		parser: jwt.NewParser(jwt.WithValidMethods(cnf.Algorithms), jwt.WithoutClaimsValidation()),
+	}, nil
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	raw := BearerToken(r)
+	if raw == "" || strings.HasPrefix(raw, API_KEY_PREFIX) {
+		return nil, nil
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(raw, claims, a.key)
+	if err == nil {
+		err = a.validate(claims, time.Now())
+	}
+	if err != nil {
+		var fetch *FetchError
+		if errors.As(err, &fetch) {
+			return nil, fetch
+		}
+		logging.FromContext(r.Context()).WithError(err).Info("Invalid jwt")
+		return nil, errors.New(UNAUTHORIZED_ERROR_MESSAGE)
+	}
+	roles := a.roles(claims)
+	subject := stringClaim(claims, "sub")
+	principal := &Principal{ID: "jwt:" + subject, Name: subject, Roles: roles}
+	for _, role := range roles {
+		principal.Scopes = append(principal.Scopes, a.cnf.Roles[strings.ToLower(role)]...)
+	}
+	return principal, nil
+}
+
+// key returns the key of the token, which must allow the signing algorithm
+func (a *jwtAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := a.keys.Key(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != "" && key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("the key %q is for %s", kid, key.Algorithm)
+	}
+	return key.Key, nil
+}
+
+// validate checks the expiry, the issuer and the audience of the token
+func (a *jwtAuthenticator) validate(claims jwt.MapClaims, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("the token doesn't expire")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(a.cnf.Leeway)) {
+		return errors.New("the token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.cnf.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("the token isn't valid yet")
+	}
+	if iss := stringClaim(claims, "iss"); iss != a.cnf.Issuer {
+		return fmt.Errorf("the token issuer %q isn't trusted", iss)
+	}
+	if !claims.VerifyAudience(a.cnf.Audience, true) {
+		return errors.New("the token isn't for this audience")
+	}
+	return nil
+}
+
+// roles reads the roles claim, following the dots into the nested claims
+func (a *jwtAuthenticator) roles(claims jwt.MapClaims) []string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(a.cnf.RolesClaim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		roles := []string{}
+		for _, role := range value {
+			if role, ok := role.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles
+	}
+	return nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// jwksServer is a local stand-in of an identity provider serving its JWKS
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []map[string]string
+	requests int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func encodeInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+// publish replaces the keys of the set, like a rotation of the provider
+func (s *jwksServer) publish(keys map[string]crypto.Signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = nil
+	for kid, signer := range keys {
+		switch key := signer.Public().(type) {
+		case *rsa.PublicKey:
+			s.keys = append(s.keys, map[string]string{
+				"kid": kid, "kty": "RSA", "use": "sig", "alg": "RS256",
+				"n": encodeInt(key.N), "e": encodeInt(big.NewInt(int64(key.E))),
+			})
+		case *ecdsa.PublicKey:
+			s.keys = append(s.keys, map[string]string{
+				"kid": kid, "kty": "EC", "crv": "P-256", "x": encodeInt(key.X), "y": encodeInt(key.Y),
+			})
+		}
+	}
+}
+
+func (s *jwksServer) fetches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.Signer, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func jwtConfig(url string) config.JWT {
+	return config.JWT{
+		Enabled:    true,
+		JWKSURL:    url,
+		Issuer:     "https://idp.example.com",
+		Audience:   "star-wars-api",
+		Algorithms: config.JWT_ALGORITHMS,
+		RolesClaim: "realm_access.roles",
+		Roles: map[string][]string{
+			"viewer": {SCOPE_PLANETS_READ},
+			"editor": {SCOPE_PLANETS_READ, SCOPE_PLANETS_WRITE},
+		},
+		Leeway:             time.Minute,
+		CacheTTL:           time.Hour,
+		MinRefreshInterval: 0,
+	}
+}
+
+func validClaims(roles ...interface{}) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":          "luke",
+		"iss":          "https://idp.example.com",
+		"aud":          []string{"star-wars-api", "other-api"},
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"realm_access": map[string]interface{}{"roles": roles},
+	}
+}
+
+func authenticate(a Authenticator, token string) (*Principal, error) {
+	req := httptest.NewRequest(http.MethodGet, "/api/planets", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return a.Authenticate(req)
+}
+
+func Test_jwtAuthenticator(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(map[string]crypto.Signer{"rsa": rsaKey, "ec": ecKey})
+	authenticator, err := NewJWTAuthenticator(jwtConfig(server.URL), NewJWKS(server.URL, time.Hour, 0))
+	assert.NoError(t, err)
+
+	expired := validClaims("editor")
+	expired["exp"] = time.Now().Add(-2 * time.Minute).Unix()
+	skewed := validClaims("editor")
+	skewed["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	noExpiry := validClaims("editor")
+	delete(noExpiry, "exp")
+	otherIssuer := validClaims("editor")
+	otherIssuer["iss"] = "https://evil.example.com"
+	otherAudience := validClaims("editor")
+	otherAudience["aud"] = "other-api"
+	notYet := validClaims("editor")
+	notYet["nbf"] = time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr string
+	}{
+		{"rs256", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims("editor")),
+			&Principal{ID: "jwt:luke", Name: "luke", Roles: []string{"editor"}, Scopes: []string{SCOPE_PLANETS_READ, SCOPE_PLANETS_WRITE}}, ""},
+		{"es256", sign(t, jwt.SigningMethodES256, "ec", ecKey, validClaims("Viewer", "unknown")),
+			&Principal{ID: "jwt:luke", Name: "luke", Roles: []string{"Viewer", "unknown"}, Scopes: []string{SCOPE_PLANETS_READ}}, ""},
+		{"no token", "", nil, ""},
+		{"api key", "swapi_key", nil, ""},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, expired), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"expired within the leeway", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, skewed),
+			&Principal{ID: "jwt:luke", Name: "luke", Roles: []string{"editor"}, Scopes: []string{SCOPE_PLANETS_READ, SCOPE_PLANETS_WRITE}}, ""},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, noExpiry), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"not valid yet", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, notYet), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"other issuer", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, otherIssuer), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"other audience", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, otherAudience), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"wrong key", sign(t, jwt.SigningMethodRS256, "rsa", otherKey, validClaims("editor")), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"unknown key id", sign(t, jwt.SigningMethodRS256, "other", otherKey, validClaims("editor")), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"algorithm of another key", sign(t, jwt.SigningMethodPS256, "rsa", rsaKey, validClaims("editor")), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"hmac", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims("editor"))
+			token.Header["kid"] = "rsa"
+			signed, _ := token.SignedString([]byte("secret"))
+			return signed
+		}(), nil, UNAUTHORIZED_ERROR_MESSAGE},
+		{"malformed", "not.a.jwt", nil, UNAUTHORIZED_ERROR_MESSAGE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := authenticate(authenticator, tt.token)
+
+			assert.Equal(t, tt.want, got)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_jwtAuthenticator_key_rotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := newJWKSServer(t)
+	server.publish(map[string]crypto.Signer{"2021": oldKey})
+	keys := NewJWKS(server.URL, time.Hour, 0)
+	assert.NoError(t, keys.Refresh())
+	authenticator, _ := NewJWTAuthenticator(jwtConfig(server.URL), keys)
+
+	_, err := authenticate(authenticator, sign(t, jwt.SigningMethodES256, "2021", oldKey, validClaims("viewer")))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, server.fetches(), "the cached keys are used")
+
+	server.publish(map[string]crypto.Signer{"2022": newKey})
+	_, err = authenticate(authenticator, sign(t, jwt.SigningMethodES256, "2022", newKey, validClaims("viewer")))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, server.fetches(), "an unknown key id fetches the keys again")
+	_, err = authenticate(authenticator, sign(t, jwt.SigningMethodES256, "2021", oldKey, validClaims("viewer")))
+	assert.EqualError(t, err, UNAUTHORIZED_ERROR_MESSAGE)
+}
+
+func TestJWKS_Key(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := newJWKSServer(t)
+	server.publish(map[string]crypto.Signer{"2021": key})
+	keys := NewJWKS(server.URL, time.Hour, time.Hour)
+
+	_, err := keys.Key("2021")
+	assert.NoError(t, err)
+	_, err = keys.Key("unknown")
+	assert.Equal(t, errUnknownKey, err)
+	assert.Equal(t, 1, server.fetches(), "the refreshes are limited by the min refresh interval")
+
+	server.Close()
+	keys = NewJWKS(server.URL, time.Hour, 0)
+	_, err = keys.Key("2021")
+	var fetch *FetchError
+	assert.ErrorAs(t, err, &fetch)
+}
+
+func TestJWKS_Key_during_refresh(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := newJWKSServer(t)
+	server.publish(map[string]crypto.Signer{"2021": key})
+	keys := NewJWKS(server.URL, time.Nanosecond, 0)
+	assert.NoError(t, keys.Refresh())
+
+	// the next fetches hang until released, like a slow identity provider
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	keys.url = slow.URL
+
+	done := make(chan error)
+	go func() {
+		_, err := keys.Key("2021")
+		_, err2 := keys.Key("2021")
+		if err == nil {
+			err = err2
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "the cached key is served while the keys are fetched")
+	case <-time.After(time.Second):
+		t.Fatal("the key waited for the refresh")
+	}
+	keys.mu.Lock()
+	assert.NotNil(t, keys.inflight, "the second key joined the refresh in flight")
+	keys.mu.Unlock()
+}
+
+func Test_jwtAuthenticator_unavailable_jwks(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := newJWKSServer(t)
+	server.Close()
+	authenticator, _ := NewJWTAuthenticator(jwtConfig(server.URL), NewJWKS(server.URL, time.Hour, 0))
+
+	_, err := authenticate(authenticator, sign(t, jwt.SigningMethodES256, "2021", key, validClaims("viewer")))
+
+	var fetch *FetchError
+	assert.ErrorAs(t, err, &fetch)
+}
+
+func TestNewJWTAuthenticator_unknown_scope(t *testing.T) {
+	cnf := jwtConfig("http://localhost")
+	cnf.Roles = map[string][]string{"editor": {"planets:delete"}}
+
+	_, err := NewJWTAuthenticator(cnf, nil)
+
+	assert.EqualError(t, err, `auth.jwt.roles.editor: unknown scope "planets:delete"`)
+}
+
+func TestNewJWTAuthenticator_default_roles(t *testing.T) {
+	cnf := jwtConfig("http://localhost")
+	cnf.Roles = config.DEFAULTS["auth.jwt.roles"].(map[string][]string)
+
+	_, err := NewJWTAuthenticator(cnf, nil)
+
+	assert.NoError(t, err, "the admin role grants the admin scope")
+}
@@ -0,0 +1,185 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+)
+
+const (
+	OPEN_ERROR_MESSAGE = "Service Unavailable"
+	// BUCKETS splits the window, the oldest bucket expires as a whole
+	BUCKETS = 10
+)
+
+type State int
+
+const (
+	STATE_CLOSED State = iota
+	STATE_HALF_OPEN
+	STATE_OPEN
+)
+
+func (s State) String() string {
+	switch s {
+	case STATE_HALF_OPEN:
+		return "half-open"
+	case STATE_OPEN:
+		return "open"
+	}
+	return "closed"
+}
+
+// OpenError is returned instead of calling through an open circuit
+type OpenError struct {
+	// RetryAfter is the time left before the circuit lets a probe through
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return OPEN_ERROR_MESSAGE
+}
+
+type bucket struct {
+	slot     int64
+	requests int
+	failures int
+}
+
+// Breaker is a circuit breaker over the failure rate of a rolling window
+type Breaker struct {
+	name string
+	cnf  config.Breaker
+	now  func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation int
+	openedAt   time.Time
+	buckets    [BUCKETS]bucket
+	probes     int
+	successes  int
+}
+
+func New(name string, cnf config.Breaker) *Breaker {
+	b := &Breaker{name: name, cnf: cnf, now: time.Now}
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(STATE_CLOSED))
+	return b
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+	return b.state
+}
+
+// Allow reserves a call, the returned function reports whether it failed.
+// It returns an *OpenError while the circuit is open, or half-open with
+// all the probes in flight.
+func (b *Breaker) Allow() (func(failed bool), error) {
+	if !b.cnf.Enabled {
+		return func(bool) {}, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+	switch b.state {
+	case STATE_OPEN:
+		metrics.CircuitBreakerRejections.WithLabelValues(b.name).Inc()
+		return nil, &OpenError{RetryAfter: b.openedAt.Add(b.cnf.OpenTimeout).Sub(b.now())}
+	case STATE_HALF_OPEN:
+		if b.probes >= b.cnf.HalfOpenRequests {
+			metrics.CircuitBreakerRejections.WithLabelValues(b.name).Inc()
+			return nil, &OpenError{RetryAfter: time.Second}
+		}
+		b.probes++
+	}
+	generation := b.generation
+	return func(failed bool) { b.done(generation, failed) }, nil
+}
+
+func (b *Breaker) done(generation int, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// the outcomes of the calls allowed before the last transition are stale
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case STATE_HALF_OPEN:
+		if failed {
+			b.transition(STATE_OPEN)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cnf.HalfOpenRequests {
+			b.transition(STATE_CLOSED)
+		}
+	case STATE_CLOSED:
+		current := b.bucket()
+		current.requests++
+		if failed {
+			current.failures++
+		}
+		requests, failures := 0, 0
+		for _, bucket := range b.buckets {
+			if b.slot()-bucket.slot < BUCKETS {
+				requests += bucket.requests
+				failures += bucket.failures
+			}
+		}
+		if requests >= b.cnf.MinRequests && float64(failures) >= b.cnf.FailureRate*float64(requests) {
+			b.transition(STATE_OPEN)
+		}
+	}
+}
+
+// expireOpen lets the probes through once the open timeout elapsed
+func (b *Breaker) expireOpen() {
+	if b.state == STATE_OPEN && !b.now().Before(b.openedAt.Add(b.cnf.OpenTimeout)) {
+		b.transition(STATE_HALF_OPEN)
+	}
+}
+
+func (b *Breaker) transition(state State) {
+	log.WithFields(log.Fields{"breaker": b.name, "from": b.state.String(), "to": state.String()}).Warn("Circuit breaker state changed")
+	b.state = state
+	b.generation++
+	b.probes, b.successes = 0, 0
+	b.buckets = [BUCKETS]bucket{}
+	if state == STATE_OPEN {
+		b.openedAt = b.now()
+	}
+	metrics.CircuitBreakerState.WithLabelValues(b.name).Set(float64(state))
+}
+
+func (b *Breaker) slot() int64 {
+	return b.now().UnixNano() / int64(b.cnf.Window/BUCKETS)
+}
+
+func (b *Breaker) bucket() *bucket {
+	slot := b.slot()
+	current := &b.buckets[slot%BUCKETS]
+	if current.slot != slot {
+		*current = bucket{slot: slot}
+	}
+	return current
+}
+
+// Check is a readiness check failing while the circuit is open
+func (b *Breaker) Check(ctx context.Context) error {
+	if b.State() == STATE_OPEN {
+		return errors.New("circuit breaker " + b.name + " is open")
+	}
+	return nil
+}
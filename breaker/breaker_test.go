@@ -0,0 +1,101 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+)
+
+var testConfig = config.Breaker{
+	Enabled:          true,
+	Window:           10 * time.Second,
+	MinRequests:      4,
+	FailureRate:      0.5,
+	OpenTimeout:      5 * time.Second,
+	HalfOpenRequests: 2,
+}
+
+// newTestBreaker returns a breaker on a clock moved by the returned function
+func newTestBreaker(name string) (*Breaker, func(time.Duration)) {
+	b := New(name, testConfig)
+	now := time.Unix(1600000000, 0)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func call(t *testing.T, b *Breaker, failed bool) error {
+	done, err := b.Allow()
+	if err == nil {
+		done(failed)
+	}
+	return err
+}
+
+func TestBreaker(t *testing.T) {
+	b, advance := newTestBreaker("test")
+
+	// 2 failures out of 5 calls keep it closed, the 3rd one reaches 50%
+	for _, failed := range []bool{false, true, false, false, true} {
+		assert.NoError(t, call(t, b, failed))
+	}
+	assert.Equal(t, STATE_CLOSED, b.State())
+	assert.NoError(t, call(t, b, true))
+	assert.Equal(t, STATE_OPEN, b.State())
+	assert.Equal(t, float64(STATE_OPEN), testutil.ToFloat64(metrics.CircuitBreakerState.WithLabelValues("test")))
+	assert.Error(t, b.Check(context.Background()))
+
+	advance(2 * time.Second)
+	err := call(t, b, false)
+	assert.IsType(t, &OpenError{}, err)
+	assert.Equal(t, 3*time.Second, err.(*OpenError).RetryAfter)
+	assert.Equal(t, OPEN_ERROR_MESSAGE, err.Error())
+
+	// half-open lets 2 probes through, a failed probe opens it again
+	advance(3 * time.Second)
+	assert.Equal(t, STATE_HALF_OPEN, b.State())
+	assert.NoError(t, b.Check(context.Background()))
+	done, err := b.Allow()
+	assert.NoError(t, err)
+	assert.NoError(t, call(t, b, true))
+	assert.Equal(t, STATE_OPEN, b.State())
+	done(false)
+	assert.Equal(t, STATE_OPEN, b.State())
+
+	// successful probes close it
+	advance(5 * time.Second)
+	first, err := b.Allow()
+	assert.NoError(t, err)
+	second, err := b.Allow()
+	assert.NoError(t, err)
+	assert.IsType(t, &OpenError{}, call(t, b, false))
+	first(false)
+	second(false)
+	assert.Equal(t, STATE_CLOSED, b.State())
+}
+
+func TestBreaker_window(t *testing.T) {
+	b, advance := newTestBreaker("window")
+
+	assert.NoError(t, call(t, b, true))
+	assert.NoError(t, call(t, b, true))
+	advance(11 * time.Second)
+	assert.NoError(t, call(t, b, true))
+	assert.NoError(t, call(t, b, false))
+
+	// the first failures left the window
+	assert.Equal(t, STATE_CLOSED, b.State())
+}
+
+func TestBreaker_disabled(t *testing.T) {
+	b := New("disabled", config.Breaker{})
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, call(t, b, true))
+	}
+	assert.Equal(t, STATE_CLOSED, b.State())
+}
@@ -0,0 +1,90 @@
+package certs
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// RELOAD_DELAY waits for both files of a renewal to be written
+const RELOAD_DELAY = 250 * time.Millisecond
+
+// Reloader serves the certificate of a cert/key pair of files and loads it
+// again when they change, so renewed certificates don't need a restart
+type Reloader struct {
+	certFile    string
+	keyFile     string
+	certificate atomic.Value
+}
+
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the files, keeping the current certificate when they are invalid
+func (r *Reloader) Reload() error {
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.certificate.Store(&certificate)
+	return nil
+}
+
+// GetCertificate is the tls.Config callback of the handshakes
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate.Load().(*tls.Certificate), nil
+}
+
+// Watch reloads the certificate when its files change, until stop is closed.
+// The directories are watched so the files replaced by a renewal are noticed.
+func (r *Reloader) Watch(stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	files := map[string]bool{}
+	for _, file := range []string{r.certFile, r.keyFile} {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+		files[file] = true
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+	go func() {
+		defer watcher.Close()
+		var reload <-chan time.Time
+		for {
+			select {
+			case event := <-watcher.Events:
+				if files[filepath.Clean(event.Name)] || filepath.Base(event.Name) == "..data" {
+					reload = time.After(RELOAD_DELAY)
+				}
+			case err := <-watcher.Errors:
+				log.WithError(err).Warn("There was an error watching the certificate files")
+			case <-reload:
+				if err := r.Reload(); err != nil {
+					log.WithError(err).Error("Kept the current certificate, the new one is invalid")
+					continue
+				}
+				log.Info("Reloaded the certificate ", r.certFile)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
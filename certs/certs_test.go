@@ -0,0 +1,99 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate for name and its key in dir
+func writeCertificate(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	certificate, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.NoError(t, err)
+	return parsed.Subject.CommonName
+}
+
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "api.example.com")
+
+	r, err := NewReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "api.example.com", commonName(t, r))
+
+	_, err = NewReloader(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.Error(t, err)
+}
+
+func TestReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "old.example.com")
+	r, err := NewReloader(certFile, keyFile)
+	assert.NoError(t, err)
+
+	ioutil.WriteFile(keyFile, []byte("not a key"), 0600)
+	assert.Error(t, r.Reload())
+	assert.Equal(t, "old.example.com", commonName(t, r), "keeps the current certificate")
+
+	writeCertificate(t, dir, "new.example.com")
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, "new.example.com", commonName(t, r))
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "old.example.com")
+	r, err := NewReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	stop := make(chan struct{})
+	defer close(stop)
+	assert.NoError(t, r.Watch(stop))
+
+	writeCertificate(t, dir, "new.example.com")
+
+	assert.Eventually(t, func() bool {
+		return commonName(t, r) == "new.example.com"
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/features"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/middleware"
+	"gopkg.in/yaml.v2"
+)
+
+var configFile = flag.String("config", "", "config file, config.yml is looked up in "+
+	"the working directory, ~/.config/star-wars-api and /etc/star-wars-api by default")
+
+// runConfigCommand handles "config print", which dumps the effective config
+// with the secrets redacted
+func runConfigCommand(cnf config.Config, args []string) {
+	action := "print"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "print":
+		if err := yaml.NewEncoder(os.Stdout).Encode(cnf.Redacted()); err != nil {
+			log.Fatal(err.Error())
+		}
+	default:
+		log.Fatalf("unknown config command %q, use print", action)
+	}
+}
+
+// watchConfig reloads the config when its file changes or on SIGHUP and
+// applies the live settings, until the server drains
+func watchConfig(loader *config.Loader, current config.Config, timeouts *middleware.Timeouts, rateLimit *middleware.RateLimit, cors *middleware.CORS, flags *features.Flags) *config.Watcher {
+	watcher := config.NewWatcher(loader, current)
+	watcher.OnReload(func(cnf config.Config) {
+		if err := logging.Setup(cnf.Logging); err != nil {
+			log.Error(err)
+		}
+		timeouts.Set(cnf.Server.Timeouts)
+		rateLimit.Set(cnf.RateLimit)
+		cors.Set(cnf.CORS)
+		flags.Set(cnf.Features)
+	})
+
+	stop := make(chan struct{})
+	onDrain(func() { close(stop) })
+	if err := watcher.Watch(stop); err != nil {
+		log.WithError(err).Warn("The config file won't be reloaded when it changes")
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hangup)
+		for {
+			select {
+			case <-hangup:
+				log.Info("Reloading the config on SIGHUP")
+				watcher.Reload()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return watcher
+}
@@ -1,37 +1,300 @@
 package config
 
 import (
-	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	"crypto/tls"
+	"time"
 )
 
 type Server struct {
-	Port string
+	Port     string
+	Timeouts Timeouts
+	// GracePeriod is how long the in-flight requests have to finish on shutdown
+	GracePeriod time.Duration
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout bound the
+	// connections of the http.Server, WriteTimeout must outlast the request
+	// timeouts so the timed out requests can still answer
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	TLS               ServerTLS
+}
+
+// ServerTLS serves https and http/2 on Port, the certificate is reloaded when
+// its files change. RedirectPort, when set, listens for plain http and
+// redirects to https.
+type ServerTLS struct {
+	Enabled  bool
+	CertFile string
+	KeyFile  string
+	// MinVersion is one of TLS_VERSIONS, "1.2" by default
+	MinVersion string
+	// CipherSuites restricts the TLS 1.2 cipher suites, by their Go names,
+	// e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
+	CipherSuites []string
+	RedirectPort string
+}
+
+var TLS_VERSIONS = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HTTP2_CIPHER_SUITES are required by http/2, one of them must be allowed
+var HTTP2_CIPHER_SUITES = []string{
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+}
+
+// CipherSuite returns the id of a secure cipher suite by name
+func CipherSuite(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// Timeouts are the request deadlines, Routes override Default for
+// the given mux path templates, e.g. "/api/planets/stats"
+type Timeouts struct {
+	Default time.Duration
+	Routes  []RouteTimeout
+}
+
+type RouteTimeout struct {
+	Path    string
+	Timeout time.Duration
 }
 
 type Database struct {
+	// Driver selects the storage, DRIVER_MONGO (default), DRIVER_MEMORY or DRIVER_BOLT
+	Driver       string
 	Uri          string
 	DatabaseName string
 	Username     string
 	Password     string
+	// PasswordFile holds the password, e.g. a docker or kubernetes secret,
+	// it takes precedence over PasswordEnv and Password
+	PasswordFile string
+	// PasswordEnv names the environment variable holding the password
+	PasswordEnv string
+	// AuthSource is the database of the user, "admin" by default
+	AuthSource string
+	// AuthMechanism is e.g. "SCRAM-SHA-256" or "MONGODB-X509", negotiated when empty
+	AuthMechanism string
+	TLS           DatabaseTLS
+	Pool          Pool
+	// ConnectTimeout and ServerSelectionTimeout bound how long the
+	// driver waits for a connection and for a suitable server, the uri or
+	// driver defaults apply when zero
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	// ReadPreference is one of READ_PREFERENCES, the one of the uri or
+	// "primary" when empty
+	ReadPreference string
+	// WriteConcern is "majority" or the number of nodes acknowledging
+	// the writes, the server default when empty
+	WriteConcern string
+	// Path is the file of the DRIVER_BOLT database
+	Path    string
+	Startup Startup
+	Breaker Breaker
+	Retry   Retry
 }
 
-// Represents database server and credentials
-type Config struct {
-	Server   Server
-	Database Database
+// Retry repeats the planets DAO calls failing with a transient mongo error,
+// up to Attempts calls in all and within the request deadline
+type Retry struct {
+	Attempts int
+	Backoff  Backoff
 }
 
-// Read and parse the Config file
-func (c *Config) Read() {
-	viper.SetConfigType("yml")
-	viper.SetConfigName("config")                                            // name of config file (without extension)
-	viper.AddConfigPath("/go/src/github.com/wallacebenevides/star-wars-api") // path to look for the config file in
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file, %s", err)
-	}
-	err := viper.Unmarshal(&c)
-	if err != nil {
-		log.Fatalf("unable to decode into struct, %v", err)
-	}
+// Breaker opens the circuit of the planets DAO when at least FailureRate of
+// the calls failed in the last Window, given MinRequests calls. The calls
+// then fail fast for OpenTimeout, after which HalfOpenRequests probes close
+// the circuit again if they all succeed.
+type Breaker struct {
+	Enabled          bool
+	Window           time.Duration
+	MinRequests      int
+	FailureRate      float64
+	OpenTimeout      time.Duration
+	HalfOpenRequests int
+}
+
+// Startup retries the first ping of the mongo database, so the server
+// survives a database starting after it
+type Startup struct {
+	// Attempts is the number of pings before giving up, 0 or 1 disables the retries
+	Attempts int
+	Backoff  Backoff
+	// Degraded starts serving when the attempts are exhausted: the readiness
+	// probe and the data routes fail until the database answers
+	Degraded bool
+}
+
+// Backoff doubles the delay between attempts from Initial up to Max, Jitter
+// is the random fraction taken off each delay, so replicas don't retry in step
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Jitter  float64
+}
+
+// DatabaseTLS encrypts the mongo connections, CAFile verifies the server
+// certificate and CertFile/KeyFile authenticate the client. KeyFile can be
+// left empty when CertFile also holds the key.
+type DatabaseTLS struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Pool sizes the connection pool of each server, zero keeps the driver defaults
+type Pool struct {
+	MaxSize     uint64
+	MinSize     uint64
+	MaxIdleTime time.Duration
+}
+
+const WRITE_CONCERN_MAJORITY = "majority"
+
+var READ_PREFERENCES = []string{"primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest"}
+
+const (
+	DRIVER_MONGO  = "mongo"
+	DRIVER_MEMORY = "memory"
+	DRIVER_BOLT   = "bolt"
+)
+
+type Migrations struct {
+	Auto bool
+}
+
+// Tracing selects where the OpenTelemetry spans are exported: "none" (default),
+// "stdout" or "otlp" (http, to Endpoint)
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+}
+
+// Logging sets the level (e.g. "debug", "info") and the format of the
+// logs, LOG_FORMAT_TEXT (default) or LOG_FORMAT_JSON
+type Logging struct {
+	Level  string
+	Format string
+}
+
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+)
+
+// Auth requires an API key or a JWT with the scopes of each route on /api,
+// without it every request may use every route but the admin ones
+type Auth struct {
+	Enabled bool
+	JWT     JWT
+}
+
+// JWT accepts the bearer tokens signed by a key of JWKSURL for Issuer and
+// Audience. The roles of the token, read from RolesClaim, grant the scopes
+// of Roles.
+type JWT struct {
+	Enabled  bool
+	JWKSURL  string
+	Issuer   string
+	Audience string
+	// Algorithms are the accepted signing algorithms, of JWT_ALGORITHMS
+	Algorithms []string
+	// RolesClaim is the claim with the roles, a list or a space separated
+	// string, dots select nested claims, e.g. "realm_access.roles"
+	RolesClaim string
+	// Roles maps the roles, in lower case, to the scopes they grant
+	Roles map[string][]string
+	// Leeway tolerates the clock skew with the issuer
+	Leeway time.Duration
+	// CacheTTL is how long the keys are cached, an unknown key id fetches
+	// them again at most once per MinRefreshInterval, e.g. after a rotation
+	CacheTTL           time.Duration
+	MinRefreshInterval time.Duration
+}
+
+var JWT_ALGORITHMS = []string{"RS256", "ES256"}
+
+// RateLimit gives each client a token bucket per group of routes, the
+// clients are the API keys and tokens, or the IP of the anonymous requests.
+// The requests of the routes out of the Groups share the Default limit.
+type RateLimit struct {
+	Enabled bool
+	Default Limit
+	Groups  []RateLimitGroup
+	// Unauthenticated limits the requests failing the authentication by IP,
+	// it is checked before the credentials so guessing them is throttled
+	Unauthenticated Limit
+	// TrustedProxies are the IPs or CIDRs of the proxies whose
+	// X-Forwarded-For header tells the client IP
+	TrustedProxies []string
+	// IdleTimeout is how long the bucket of an idle client is kept
+	IdleTimeout time.Duration
+}
+
+// Limit allows Requests per Period, up to Burst at once (Requests when 0)
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// RateLimitGroup limits the routes of Paths, mux path templates, for the
+// Methods, all of them when empty
+type RateLimitGroup struct {
+	Name    string
+	Paths   []string
+	Methods []string
+	Limit   `mapstructure:",squash" yaml:",inline"`
+}
+
+// CORS lets the browsers call the API from AllowedOrigins, e.g.
+// "https://app.example.com", "https://*.example.com" for its subdomains or
+// "*" for any origin
+type CORS struct {
+	Enabled          bool
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long the browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// Features switch the optional behaviors of the API, they apply without a
+// restart
+type Features struct {
+	// ExpandResidents allows ?expand=residents on the planet routes
+	ExpandResidents bool
+	// LegacyFormat allows ?legacyFormat=true on the planet routes
+	LegacyFormat bool
+}
+
+// Represents database server and credentials
+type Config struct {
+	Server     Server
+	Database   Database
+	Migrations Migrations
+	Tracing    Tracing
+	Logging    Logging
+	Auth       Auth
+	RateLimit  RateLimit
+	CORS       CORS
+	Features   Features
 }
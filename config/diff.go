@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// LIVE_KEYS are the keys, or the prefixes ending with a dot, applied without
+// restarting the server when the config is reloaded
+var LIVE_KEYS = []string{
+	"logging.",
+	"server.timeouts.",
+	"ratelimit.",
+	"cors.",
+	"features.",
+}
+
+// Change is a key whose value differs between two configs
+type Change struct {
+	Key string
+	Old string
+	New string
+	// Live is false when the server has to restart to apply the change
+	Live bool
+}
+
+// Diff lists the changed keys from old to new, sorted by key. The secrets
+// are redacted, so the changes can be logged.
+func Diff(old, new Config) []Change {
+	before, after := flatten(old.Redacted()), flatten(new.Redacted())
+	changes := []Change{}
+	for key, value := range after {
+		if previous := before[key]; !reflect.DeepEqual(previous, value) {
+			changes = append(changes, Change{
+				Key:  key,
+				Old:  fmt.Sprint(previous),
+				New:  fmt.Sprint(value),
+				Live: IsLive(key),
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// IsLive tells if a key is in LIVE_KEYS
+func IsLive(key string) bool {
+	for _, live := range LIVE_KEYS {
+		if key == live || (strings.HasSuffix(live, ".") && strings.HasPrefix(key, live)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyLive returns applied with the values of the LIVE_KEYS of next, the
+// other keys keep the values the process runs with
+func ApplyLive(applied, next Config) Config {
+	merged := applied
+	var walk func(prefix string, dst, src reflect.Value)
+	walk = func(prefix string, dst, src reflect.Value) {
+		if IsLive(prefix) {
+			dst.Set(src)
+			return
+		}
+		if dst.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < dst.NumField(); i++ {
+			key := strings.ToLower(dst.Type().Field(i).Name)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walk(key, dst.Field(i), src.Field(i))
+		}
+	}
+	walk("", reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next))
+	return merged
+}
+
+// flatten maps the config keys, e.g. "server.timeouts.default", to their
+// values, lists and maps are single values
+func flatten(c Config) map[string]interface{} {
+	values := map[string]interface{}{}
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		if v.Kind() != reflect.Struct {
+			values[prefix] = v.Interface()
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			key := strings.ToLower(v.Type().Field(i).Name)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walk(key, v.Field(i))
+		}
+	}
+	walk("", reflect.ValueOf(c))
+	return values
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := validConfig()
+	new := validConfig()
+	new.Logging.Level = "debug"
+	new.Server.Timeouts.Routes = []RouteTimeout{{Path: "/api/planets/stats", Timeout: 30 * time.Second}}
+	new.Database.Password = "secret"
+
+	assert.Equal(t, []Change{
+		{Key: "database.password", Old: "", New: REDACTED, Live: false},
+		{Key: "logging.level", Old: "info", New: "debug", Live: true},
+		{Key: "server.timeouts.routes", Old: "[]", New: "[{/api/planets/stats 30s}]", Live: true},
+	}, Diff(old, new))
+	assert.Empty(t, Diff(old, validConfig()))
+}
+
+func TestApplyLive(t *testing.T) {
+	applied := validConfig()
+	next := validConfig()
+	next.Logging.Level = "debug"
+	next.Server.Port = "9090"
+	next.Server.Timeouts.Default = time.Minute
+	next.Features.LegacyFormat = true
+
+	merged := ApplyLive(applied, next)
+
+	assert.Equal(t, "debug", merged.Logging.Level)
+	assert.Equal(t, time.Minute, merged.Server.Timeouts.Default)
+	assert.True(t, merged.Features.LegacyFormat)
+	assert.Equal(t, "8080", merged.Server.Port)
+	assert.Equal(t, "info", applied.Logging.Level, "applied is left as it is")
+}
+
+func TestIsLive(t *testing.T) {
+	assert.True(t, IsLive("logging.level"))
+	assert.True(t, IsLive("server.timeouts.default"))
+	assert.True(t, IsLive("ratelimit.groups"))
+	assert.True(t, IsLive("cors.allowedorigins"))
+	assert.True(t, IsLive("features.legacyformat"))
+	assert.False(t, IsLive("server.port"))
+	assert.False(t, IsLive("server.timeoutsx"))
+}
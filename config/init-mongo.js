@@ -1,124 +0,0 @@
-db.createCollection("planets")
-db.planets.insert([
-    {
-        "name": "Alderaan",
-        "climate": "temperate",
-        "terrain": "grasslands, mountains",
-        "films": 2
-    },
-    {
-        "name": "Yavin IV",
-        "climate": "temperate, tropical",
-        "terrain": "jungle, rainforests",
-        "films": 3
-    },
-    {
-        "name": "Hoth",
-        "climate": "frozen",
-        "terrain": "tundra, ice caves, mountain ranges",
-        "films": 1
-    },
-    {
-        "name": "Dagobah",
-        "climate": "murky",
-        "terrain": "swamp, jungles",
-        "films": 3
-    },
-    {
-        "name": "Bespin",
-        "climate": "temperate",
-        "terrain": "gas giant",
-        "films": 1
-    },
-    {
-        "name": "Endor",
-        "climate": "temperate",
-        "terrain": "forests, mountains, lakes",
-        "films": 1
-    },
-    {
-        "name": "Naboo",
-        "climate": "temperate",
-        "terrain": "grassy hills, swamps, forests, mountains",
-        "films": 4
-    },
-    {
-        "name": "Coruscant",
-        "climate": "temperate",
-        "terrain": "cityscape, mountains",
-        "films": 4
-    },
-    {
-        "name": "Kamino",
-        "climate": "temperate",
-        "terrain": "ocean",
-        "films": 1
-    },
-    {
-        "name": "Geonosis",
-        "climate": "temperate, arid",
-        "terrain": "rock, desert, mountain, barren",
-        "films": 1
-    },
-    {
-        "name": "Utapau",
-        "climate": "temperate, arid, windy",
-        "terrain": "scrublands, savanna, canyons, sinkholes",
-        "films": 1
-    },
-    {
-        "name": "Mustafar",
-        "climate": "hot",
-        "terrain": "volcanoes, lava rivers, mountains, caves",
-        "films": 1
-    },
-    {
-        "name": "Kashyyyk",
-        "climate": "tropical",
-        "terrain": "jungle, forests, lakes, rivers",
-        "films": 1
-    },
-    {
-        "name": "Polis Massa",
-        "climate": "artificial temperate ",
-        "terrain": "airless asteroid",
-        "films": 1
-    },
-    {
-        "name": "Mygeeto",
-        "climate": "frigid",
-        "terrain": "glaciers, mountains, ice canyons",
-        "films": 1
-    },
-    {
-        "name": "Felucia",
-        "climate": "hot, humid",
-        "terrain": "fungus forests",
-        "films": 1
-    },
-    {
-        "name": "Cato Neimoidia",
-        "climate": "temperate, moist",
-        "terrain": "mountains, fields, forests, rock arches",
-        "films": 1
-    },
-    {
-        "name": "Saleucami",
-        "climate": "hot",
-        "terrain": "caves, desert, mountains, volcanoes",
-        "films": 1
-    },
-    {
-        "name": "Stewjon",
-        "climate": "temperate",
-        "terrain": "grass",
-        "films": 0
-    },
-    {
-        "name": "Eriadu",
-        "climate": "polluted",
-        "terrain": "cityscape",
-        "films": 0
-    }
-])
-
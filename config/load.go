@@ -0,0 +1,228 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+const (
+	CONFIG_NAME = "config"
+	// ENV_PREFIX prefixes the environment variables overriding the config keys,
+	// e.g. SWAPI_DATABASE_URI for database.uri
+	ENV_PREFIX = "SWAPI"
+	// REDACTED replaces the secrets, as url.URL.Redacted does
+	REDACTED = "xxxxx"
+)
+
+// SEARCH_PATHS are the directories looked up for config.yml when no file is given
+var SEARCH_PATHS = []string{
+	".",
+	"$HOME/.config/star-wars-api",
+	"/etc/star-wars-api",
+	"/go/src/github.com/wallacebenevides/star-wars-api",
+}
+
+// DEFAULTS are the values of the keys missing from the file and the environment,
+// every key has one so the environment can override it
+var DEFAULTS = map[string]interface{}{
+	"server.port":                       "8080",
+	"server.graceperiod":                "15s",
+	"server.timeouts.default":           "10s",
+	"server.timeouts.routes":            []RouteTimeout{},
+	"server.readheadertimeout":          "5s",
+	"server.readtimeout":                "30s",
+	"server.writetimeout":               "60s",
+	"server.idletimeout":                "120s",
+	"server.maxheaderbytes":             1 << 20,
+	"server.tls.enabled":                false,
+	"server.tls.certfile":               "",
+	"server.tls.keyfile":                "",
+	"server.tls.minversion":             "1.2",
+	"server.tls.ciphersuites":           []string{},
+	"server.tls.redirectport":           "",
+	"database.driver":                   DRIVER_MONGO,
+	"database.uri":                      "mongodb://localhost:27017",
+	"database.databasename":             "star_wars_db",
+	"database.username":                 "",
+	"database.password":                 "",
+	"database.passwordfile":             "",
+	"database.passwordenv":              "",
+	"database.authsource":               "",
+	"database.authmechanism":            "",
+	"database.tls.enabled":              false,
+	"database.tls.cafile":               "",
+	"database.tls.certfile":             "",
+	"database.tls.keyfile":              "",
+	"database.tls.insecureskipverify":   false,
+	"database.pool.maxsize":             0,
+	"database.pool.minsize":             0,
+	"database.pool.maxidletime":         "0s",
+	"database.connecttimeout":           "0s",
+	"database.serverselectiontimeout":   "0s",
+	"database.readpreference":           "",
+	"database.writeconcern":             "",
+	"database.startup.attempts":         10,
+	"database.startup.backoff.initial":  "500ms",
+	"database.startup.backoff.max":      "10s",
+	"database.startup.backoff.jitter":   0.5,
+	"database.startup.degraded":         false,
+	"database.breaker.enabled":          true,
+	"database.breaker.window":           "30s",
+	"database.breaker.minrequests":      10,
+	"database.breaker.failurerate":      0.5,
+	"database.breaker.opentimeout":      "10s",
+	"database.breaker.halfopenrequests": 1,
+	"database.retry.attempts":           3,
+	"database.retry.backoff.initial":    "50ms",
+	"database.retry.backoff.max":        "1s",
+	"database.retry.backoff.jitter":     0.5,
+	"database.path":                     "star_wars.db",
+	"migrations.auto":                   true,
+	"tracing.exporter":                  "none",
+	"tracing.endpoint":                  "localhost:4318",
+	"tracing.insecure":                  false,
+	"tracing.servicename":               "star-wars-api",
+	"tracing.sampleratio":               1.0,
+	"logging.level":                     "info",
+	"logging.format":                    LOG_FORMAT_TEXT,
+	"auth.enabled":                      false,
+	"auth.jwt.enabled":                  false,
+	"auth.jwt.jwksurl":                  "",
+	"auth.jwt.issuer":                   "",
+	"auth.jwt.audience":                 "",
+	"auth.jwt.algorithms":               JWT_ALGORITHMS,
+	"auth.jwt.rolesclaim":               "roles",
+	"auth.jwt.roles": map[string][]string{
+		"viewer": {"planets:read", "films:read", "people:read"},
+		"editor": {"planets:read", "planets:write", "films:read", "films:write", "people:read", "people:write"},
+		"admin":  {"admin"},
+	},
+	"auth.jwt.leeway":                    "30s",
+	"auth.jwt.cachettl":                  "1h",
+	"auth.jwt.minrefreshinterval":        "1m",
+	"ratelimit.enabled":                  false,
+	"ratelimit.default.requests":         100,
+	"ratelimit.default.period":           "1m",
+	"ratelimit.default.burst":            0,
+	"ratelimit.groups":                   []RateLimitGroup{},
+	"ratelimit.unauthenticated.requests": 10,
+	"ratelimit.unauthenticated.period":   "1m",
+	"ratelimit.unauthenticated.burst":    0,
+	"ratelimit.trustedproxies":           []string{},
+	"ratelimit.idletimeout":              "10m",
+	"cors.enabled":                       false,
+	"cors.allowedorigins":                []string{},
+	"cors.allowedmethods":                []string{"GET", "POST", "PUT", "DELETE"},
+	"cors.allowedheaders":                []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-ID"},
+	"cors.exposedheaders":                []string{"X-Request-ID", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+	"cors.allowcredentials":              false,
+	"cors.maxage":                        "10m",
+	"features.expandresidents":           true,
+	"features.legacyformat":              true,
+}
+
+// Loader reads the config from the defaults, the config file and the
+// SWAPI_ environment variables, the later ones taking precedence
+type Loader struct {
+	v *viper.Viper
+}
+
+// NewLoader looks for the given file, or for config.yml in SEARCH_PATHS
+// when file is empty
+func NewLoader(file string) *Loader {
+	v := viper.New()
+	for key, value := range DEFAULTS {
+		v.SetDefault(key, value)
+	}
+	v.SetConfigType("yml")
+	if file != "" {
+		v.SetConfigFile(file)
+	} else {
+		v.SetConfigName(CONFIG_NAME)
+		for _, path := range SEARCH_PATHS {
+			v.AddConfigPath(os.ExpandEnv(path))
+		}
+	}
+	v.SetEnvPrefix(ENV_PREFIX)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	return &Loader{v: v}
+}
+
+// Load reads and validates the config, a missing file is only an error
+// when it was given explicitly
+func (l *Loader) Load() (Config, error) {
+	var c Config
+	if err := l.v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return c, fmt.Errorf("error reading config file: %v", err)
+		}
+	}
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		stringToRouteTimeoutsHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err := l.v.Unmarshal(&c, hook); err != nil {
+		return c, fmt.Errorf("unable to decode the config: %v", err)
+	}
+	return c, c.Validate()
+}
+
+// File is the config file that was read, empty when running on the defaults
+func (l *Loader) File() string {
+	return l.v.ConfigFileUsed()
+}
+
+// Load reads the config like NewLoader(file).Load()
+func Load(file string) (Config, error) {
+	return NewLoader(file).Load()
+}
+
+// stringToRouteTimeoutsHook decodes the route timeouts of the environment,
+// e.g. SWAPI_SERVER_TIMEOUTS_ROUTES="/api/planets/stats=30s,/api/films=5s"
+func stringToRouteTimeoutsHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf([]RouteTimeout{}) {
+		return data, nil
+	}
+	routes := []RouteTimeout{}
+	for _, pair := range strings.Split(data.(string), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid route timeout %q, use path=duration", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, RouteTimeout{Path: strings.TrimSpace(parts[0]), Timeout: timeout})
+	}
+	return routes, nil
+}
+
+// Redacted returns a copy of the config safe to print, without the database
+// password, also when it is part of the uri
+func (c Config) Redacted() Config {
+	c.Database = c.Database.Redacted()
+	return c
+}
+
+func (d Database) Redacted() Database {
+	if d.Password != "" {
+		d.Password = REDACTED
+	}
+	if u, err := url.Parse(d.Uri); err == nil {
+		d.Uri = u.Redacted()
+	}
+	return d
+}
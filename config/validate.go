@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Validate checks the values that would only fail later, at startup or on
+// the first request, and reports all of them at once
+func (c Config) Validate() error {
+	var problems []string
+	invalid := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		invalid("server.port %q is not a port number between 1 and 65535", c.Server.Port)
+	}
+	if c.Server.GracePeriod < 0 {
+		invalid("server.graceperiod can't be negative")
+	}
+	if c.Server.ReadHeaderTimeout < 0 || c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		invalid("server connection timeouts can't be negative")
+	}
+	if c.Server.MaxHeaderBytes < 0 {
+		invalid("server.maxheaderbytes can't be negative")
+	}
+	if write := c.Server.WriteTimeout; write > 0 {
+		if c.Server.Timeouts.Default <= 0 || c.Server.Timeouts.Default >= write {
+			invalid("server.writetimeout %v must be greater than server.timeouts.default", write)
+		}
+		for i, route := range c.Server.Timeouts.Routes {
+			if route.Timeout >= write {
+				invalid("server.writetimeout %v must be greater than server.timeouts.routes[%d].timeout", write, i)
+			}
+		}
+	}
+	if tls := c.Server.TLS; tls.Enabled {
+		if tls.CertFile == "" || tls.KeyFile == "" {
+			invalid("server.tls.certfile and server.tls.keyfile are required with tls")
+		}
+		if _, ok := TLS_VERSIONS[tls.MinVersion]; tls.MinVersion != "" && !ok {
+			invalid("server.tls.minversion %q is unknown, use 1.2 or 1.3", tls.MinVersion)
+		}
+		http2 := len(tls.CipherSuites) == 0
+		for _, name := range tls.CipherSuites {
+			if _, ok := CipherSuite(name); !ok {
+				invalid("server.tls.ciphersuites %q isn't a secure cipher suite", name)
+			}
+			http2 = http2 || contains(HTTP2_CIPHER_SUITES, name)
+		}
+		if !http2 {
+			invalid("server.tls.ciphersuites must include one of %s for http/2", strings.Join(HTTP2_CIPHER_SUITES, ", "))
+		}
+		if tls.RedirectPort != "" && tls.RedirectPort == c.Server.Port {
+			invalid("server.tls.redirectport must differ from server.port")
+		}
+	}
+	if c.Server.Timeouts.Default < 0 {
+		invalid("server.timeouts.default can't be negative")
+	}
+	for i, route := range c.Server.Timeouts.Routes {
+		if !strings.HasPrefix(route.Path, "/") {
+			invalid("server.timeouts.routes[%d].path %q must start with /", i, route.Path)
+		}
+		if route.Timeout <= 0 {
+			invalid("server.timeouts.routes[%d].timeout must be positive", i)
+		}
+	}
+
+	switch c.Database.Driver {
+	case DRIVER_MONGO:
+		if c.Database.Uri == "" {
+			invalid("database.uri is required by the %s driver", DRIVER_MONGO)
+		}
+		if c.Database.DatabaseName == "" {
+			invalid("database.databasename is required by the %s driver", DRIVER_MONGO)
+		}
+		if c.Database.ReadPreference != "" && !contains(READ_PREFERENCES, c.Database.ReadPreference) {
+			invalid("database.readpreference %q is unknown, use %s", c.Database.ReadPreference, strings.Join(READ_PREFERENCES, ", "))
+		}
+		if w := c.Database.WriteConcern; w != "" && w != WRITE_CONCERN_MAJORITY {
+			if n, err := strconv.Atoi(w); err != nil || n < 0 {
+				invalid("database.writeconcern %q must be %s or a number of nodes", w, WRITE_CONCERN_MAJORITY)
+			}
+		}
+		if c.Database.Pool.MaxSize > 0 && c.Database.Pool.MinSize > c.Database.Pool.MaxSize {
+			invalid("database.pool.minsize can't be greater than database.pool.maxsize")
+		}
+		if c.Database.TLS.KeyFile != "" && c.Database.TLS.CertFile == "" {
+			invalid("database.tls.keyfile needs database.tls.certfile")
+		}
+		if (c.Database.TLS.CAFile != "" || c.Database.TLS.CertFile != "") && !c.Database.TLS.Enabled {
+			invalid("database.tls.enabled must be true to use the tls files")
+		}
+		if c.Database.ConnectTimeout < 0 || c.Database.ServerSelectionTimeout < 0 {
+			invalid("database timeouts can't be negative")
+		}
+		if c.Database.Startup.Attempts < 0 {
+			invalid("database.startup.attempts can't be negative")
+		}
+		validateBackoff("database.startup.backoff", c.Database.Startup.Backoff, invalid)
+		// the degraded server pings the database with the backoff until it answers
+		if c.Database.Startup.Degraded && c.Database.Startup.Backoff.Initial <= 0 {
+			invalid("database.startup.backoff.initial must be positive with database.startup.degraded")
+		}
+	case DRIVER_BOLT:
+		if c.Database.Path == "" {
+			invalid("database.path is required by the %s driver", DRIVER_BOLT)
+		}
+	case DRIVER_MEMORY:
+	default:
+		invalid("database.driver %q is unknown, use %s, %s or %s", c.Database.Driver, DRIVER_MONGO, DRIVER_BOLT, DRIVER_MEMORY)
+	}
+
+	if c.Database.Retry.Attempts < 0 {
+		invalid("database.retry.attempts can't be negative")
+	}
+	validateBackoff("database.retry.backoff", c.Database.Retry.Backoff, invalid)
+
+	if b := c.Database.Breaker; b.Enabled {
+		if b.Window <= 0 || b.OpenTimeout <= 0 {
+			invalid("database.breaker.window and database.breaker.opentimeout must be positive")
+		}
+		if b.FailureRate <= 0 || b.FailureRate > 1 {
+			invalid("database.breaker.failurerate %v must be greater than 0 and at most 1", b.FailureRate)
+		}
+		if b.MinRequests < 1 || b.HalfOpenRequests < 1 {
+			invalid("database.breaker.minrequests and database.breaker.halfopenrequests must be at least 1")
+		}
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sampleratio %v must be between 0 and 1", c.Tracing.SampleRatio)
+	}
+
+	if c.Logging.Level != "" {
+		if _, err := log.ParseLevel(c.Logging.Level); err != nil {
+			invalid("logging.level %q is unknown", c.Logging.Level)
+		}
+	}
+	switch c.Logging.Format {
+	case "", LOG_FORMAT_TEXT, LOG_FORMAT_JSON:
+	default:
+		invalid("logging.format %q is unknown, use %s or %s", c.Logging.Format, LOG_FORMAT_TEXT, LOG_FORMAT_JSON)
+	}
+
+	if jwt := c.Auth.JWT; jwt.Enabled {
+		if !c.Auth.Enabled {
+			invalid("auth.enabled must be true to use jwt")
+		}
+		if u, err := url.Parse(jwt.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			invalid("auth.jwt.jwksurl %q must be an http or https url", jwt.JWKSURL)
+		}
+		if jwt.Issuer == "" || jwt.Audience == "" {
+			invalid("auth.jwt.issuer and auth.jwt.audience are required with jwt")
+		}
+		if len(jwt.Algorithms) == 0 {
+			invalid("auth.jwt.algorithms can't be empty")
+		}
+		for _, algorithm := range jwt.Algorithms {
+			if !contains(JWT_ALGORITHMS, algorithm) {
+				invalid("auth.jwt.algorithms %q is unknown, use %s", algorithm, strings.Join(JWT_ALGORITHMS, " or "))
+			}
+		}
+		if jwt.RolesClaim == "" {
+			invalid("auth.jwt.rolesclaim is required with jwt")
+		}
+		if jwt.Leeway < 0 || jwt.CacheTTL <= 0 || jwt.MinRefreshInterval < 0 {
+			invalid("auth.jwt.cachettl must be positive, auth.jwt.leeway and auth.jwt.minrefreshinterval can't be negative")
+		}
+	}
+	if rl := c.RateLimit; rl.Enabled {
+		validateLimit("ratelimit.default", rl.Default, invalid)
+		names := map[string]bool{}
+		for i, group := range rl.Groups {
+			key := fmt.Sprintf("ratelimit.groups[%d]", i)
+			if group.Name == "" || names[group.Name] {
+				invalid("%s.name must be set and unique", key)
+			}
+			names[group.Name] = true
+			if len(group.Paths) == 0 {
+				invalid("%s.paths can't be empty", key)
+			}
+			for _, path := range group.Paths {
+				if !strings.HasPrefix(path, "/") {
+					invalid("%s.paths %q must start with /", key, path)
+				}
+			}
+			validateLimit(key, group.Limit, invalid)
+		}
+		validateLimit("ratelimit.unauthenticated", rl.Unauthenticated, invalid)
+		for _, proxy := range rl.TrustedProxies {
+			if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+				invalid("ratelimit.trustedproxies %q isn't an IP or a CIDR", proxy)
+			}
+		}
+		if rl.IdleTimeout <= 0 {
+			invalid("ratelimit.idletimeout must be positive")
+		}
+	}
+	if cors := c.CORS; cors.Enabled {
+		if len(cors.AllowedOrigins) == 0 {
+			invalid("cors.allowedorigins can't be empty with cors")
+		}
+		for _, origin := range cors.AllowedOrigins {
+			if origin == "*" {
+				if cors.AllowCredentials {
+					invalid("cors.allowedorigins can't be * with cors.allowcredentials")
+				}
+				continue
+			}
+			u, err := url.Parse(strings.Replace(origin, "*.", "", 1))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || strings.Count(origin, "*") > 1 ||
+				(strings.Contains(origin, "*") && !strings.HasPrefix(origin, u.Scheme+"://*.")) {
+				invalid("cors.allowedorigins %q must be a scheme and a host, e.g. https://*.example.com", origin)
+			}
+		}
+		if cors.MaxAge < 0 {
+			invalid("cors.maxage can't be negative")
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateLimit(key string, l Limit, invalid func(format string, args ...interface{})) {
+	if l.Requests <= 0 || l.Period <= 0 || l.Burst < 0 {
+		invalid("%s.requests and %s.period must be positive and %s.burst can't be negative", key, key, key)
+	}
+}
+
+func validateBackoff(key string, b Backoff, invalid func(format string, args ...interface{})) {
+	if b.Initial < 0 || b.Max < b.Initial {
+		invalid("%s.initial can't be negative nor greater than %s.max", key, key)
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		invalid("%s.jitter %v must be between 0 and 1", key, b.Jitter)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
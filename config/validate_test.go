@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig() Config {
+	return Config{
+		Server:   Server{Port: "8080", Timeouts: Timeouts{Default: 10 * time.Second}},
+		Database: Database{Driver: DRIVER_MONGO, Uri: "mongodb://mongodb:27017", DatabaseName: "star_wars_db"},
+		Tracing:  Tracing{SampleRatio: 1},
+		Logging:  Logging{Level: "info", Format: LOG_FORMAT_JSON},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"memory driver", func(c *Config) { c.Database = Database{Driver: DRIVER_MEMORY} }, ""},
+		{"port out of range", func(c *Config) { c.Server.Port = "70000" },
+			`invalid config: server.port "70000" is not a port number between 1 and 65535`},
+		{"missing uri", func(c *Config) { c.Database.Uri = "" },
+			"invalid config: database.uri is required by the mongo driver"},
+		{"missing bolt path", func(c *Config) { c.Database.Driver = DRIVER_BOLT },
+			"invalid config: database.path is required by the bolt driver"},
+		{"route timeout", func(c *Config) { c.Server.Timeouts.Routes = []RouteTimeout{{Path: "api/films"}} },
+			`invalid config: server.timeouts.routes[0].path "api/films" must start with /; server.timeouts.routes[0].timeout must be positive`},
+		{"read preference and write concern", func(c *Config) { c.Database.ReadPreference = "any"; c.Database.WriteConcern = "all" },
+			`invalid config: database.readpreference "any" is unknown, use primary, primaryPreferred, secondary, secondaryPreferred, nearest; database.writeconcern "all" must be majority or a number of nodes`},
+		{"write timeout shorter than a request timeout", func(c *Config) { c.Server.WriteTimeout = 10 * time.Second },
+			"invalid config: server.writetimeout 10s must be greater than server.timeouts.default"},
+		{"server tls", func(c *Config) {
+			c.Server.TLS = ServerTLS{Enabled: true, MinVersion: "1.0", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}, RedirectPort: "8080"}
+		}, `invalid config: server.tls.certfile and server.tls.keyfile are required with tls; server.tls.minversion "1.0" is unknown, use 1.2 or 1.3; server.tls.ciphersuites "TLS_RSA_WITH_RC4_128_SHA" isn't a secure cipher suite; server.tls.ciphersuites must include one of TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 for http/2; server.tls.redirectport must differ from server.port`},
+		{"jwt", func(c *Config) {
+			c.Auth.JWT = JWT{Enabled: true, JWKSURL: "idp/keys", Algorithms: []string{"HS256"}, RolesClaim: "roles", CacheTTL: time.Hour}
+		}, `invalid config: auth.enabled must be true to use jwt; auth.jwt.jwksurl "idp/keys" must be an http or https url; auth.jwt.issuer and auth.jwt.audience are required with jwt; auth.jwt.algorithms "HS256" is unknown, use RS256 or ES256`},
+		{"rate limit", func(c *Config) {
+			c.RateLimit = RateLimit{
+				Enabled:        true,
+				Default:        Limit{Requests: 100, Period: time.Minute},
+				Groups:         []RateLimitGroup{{Name: "list", Paths: []string{"api/planets"}}, {Name: "list", Paths: []string{"/api/films"}, Limit: Limit{Requests: 1, Period: time.Second}}},
+				TrustedProxies: []string{"10.0.0.0/8", "proxy"},
+				IdleTimeout:    time.Minute,
+			}
+		}, `invalid config: ratelimit.groups[0].paths "api/planets" must start with /; ratelimit.groups[0].requests and ratelimit.groups[0].period must be positive and ratelimit.groups[0].burst can't be negative; ratelimit.groups[1].name must be set and unique; ratelimit.unauthenticated.requests and ratelimit.unauthenticated.period must be positive and ratelimit.unauthenticated.burst can't be negative; ratelimit.trustedproxies "proxy" isn't an IP or a CIDR`},
+		{"cors", func(c *Config) {
+			c.CORS = CORS{Enabled: true, AllowCredentials: true, AllowedOrigins: []string{
+				"https://app.example.com", "https://*.example.com:8443", "*", "app.example.com", "https://app.*.com", "https://app.example.com/",
+			}}
+		}, `invalid config: cors.allowedorigins can't be * with cors.allowcredentials; cors.allowedorigins "app.example.com" must be a scheme and a host, e.g. https://*.example.com; cors.allowedorigins "https://app.*.com" must be a scheme and a host, e.g. https://*.example.com; cors.allowedorigins "https://app.example.com/" must be a scheme and a host, e.g. https://*.example.com`},
+		{"degraded without backoff", func(c *Config) { c.Database.Startup = Startup{Degraded: true} },
+			"invalid config: database.startup.backoff.initial must be positive with database.startup.degraded"},
+		{"tls files without tls", func(c *Config) { c.Database.TLS.CAFile = "ca.pem" },
+			"invalid config: database.tls.enabled must be true to use the tls files"},
+		{"sample ratio", func(c *Config) { c.Tracing.SampleRatio = 2 },
+			"invalid config: tracing.sampleratio 2 must be between 0 and 1"},
+		{"log level and format", func(c *Config) { c.Logging = Logging{Level: "loud", Format: "xml"} },
+			`invalid config: logging.level "loud" is unknown; logging.format "xml" is unknown, use text or json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.change(&c)
+			err := c.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
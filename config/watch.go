@@ -0,0 +1,126 @@
+package config
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// RELOAD_DELAY groups the bursts of file events of a single save
+const RELOAD_DELAY = 250 * time.Millisecond
+
+// Watcher keeps the current config and reloads it on demand or when its
+// file changes, a config that fails to load or validate is discarded. Only
+// the live keys of a reloaded config are applied, the current config keeps
+// the values of the others until a restart.
+type Watcher struct {
+	loader    *Loader
+	reloading sync.Mutex
+	mu        sync.RWMutex
+	current   Config
+	listeners []func(Config)
+}
+
+func NewWatcher(loader *Loader, current Config) *Watcher {
+	return &Watcher{loader: loader, current: current}
+}
+
+// Current is the config the process runs with
+func (w *Watcher) Current() Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// OnReload registers a listener applying the live settings of the reloaded
+// config, listeners run in registration order
+func (w *Watcher) OnReload(listener func(Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, listener)
+}
+
+// Reload reads the config again, logs what changed and, when it is valid,
+// applies its live keys to the current config and calls the listeners with
+// it. The changes needing a restart are logged on every reload until then.
+func (w *Watcher) Reload() ([]Change, error) {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+	next, err := w.loader.Load()
+	if err != nil {
+		log.WithError(err).Error("Kept the current config, the new one is invalid")
+		return nil, err
+	}
+
+	w.mu.Lock()
+	changes := Diff(w.current, next)
+	w.current = ApplyLive(w.current, next)
+	applied := w.current
+	listeners := append([]func(Config){}, w.listeners...)
+	w.mu.Unlock()
+
+	for _, change := range changes {
+		entry := log.WithFields(log.Fields{"key": change.Key, "old": change.Old, "new": change.New})
+		if change.Live {
+			entry.Info("Config changed")
+		} else {
+			entry.Warn("Config changed, restart the server to apply it")
+		}
+	}
+	live := false
+	for _, change := range changes {
+		live = live || change.Live
+	}
+	if !live {
+		log.Info("Config reloaded without live changes")
+		return changes, nil
+	}
+	for _, listener := range listeners {
+		listener(applied)
+	}
+	return changes, nil
+}
+
+// Watch reloads the config when its file is written, until stop is closed.
+// The directory is watched so the editors and the kubernetes volumes
+// replacing the file are also noticed.
+func (w *Watcher) Watch(stop <-chan struct{}) error {
+	file := w.loader.File()
+	if file == "" {
+		return nil
+	}
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		var reload <-chan time.Time
+		for {
+			select {
+			case event := <-watcher.Events:
+				if filepath.Clean(event.Name) == file || filepath.Base(event.Name) == "..data" {
+					reload = time.After(RELOAD_DELAY)
+				}
+			case err := <-watcher.Errors:
+				log.WithError(err).Warn("There was an error watching the config file")
+			case <-reload:
+				w.Reload()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	file := writeConfig(t, "logging:\n  level: info\n")
+	loader := NewLoader(file)
+	current, err := loader.Load()
+	assert.NoError(t, err)
+	watcher := NewWatcher(loader, current)
+	var applied []Config
+	watcher.OnReload(func(c Config) { applied = append(applied, c) })
+
+	ioutil.WriteFile(file, []byte("logging:\n  level: debug\nserver:\n  port: \"9090\"\n"), 0644)
+	changes, err := watcher.Reload()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Key: "logging.level", Old: "info", New: "debug", Live: true},
+		{Key: "server.port", Old: "8080", New: "9090", Live: false},
+	}, changes)
+	assert.Equal(t, "debug", watcher.Current().Logging.Level)
+	assert.Equal(t, "8080", watcher.Current().Server.Port, "the port applies after a restart")
+	assert.Len(t, applied, 1)
+	assert.Equal(t, watcher.Current(), applied[0])
+
+	changes, err = watcher.Reload()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Key: "server.port", Old: "8080", New: "9090", Live: false}}, changes, "the restart is still needed")
+	assert.Len(t, applied, 1, "no live change")
+
+	ioutil.WriteFile(file, []byte("logging:\n  level: loud\n"), 0644)
+	_, err = watcher.Reload()
+
+	assert.Error(t, err)
+	assert.Equal(t, "debug", watcher.Current().Logging.Level)
+	assert.Len(t, applied, 1)
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	file := writeConfig(t, "logging:\n  level: info\n")
+	loader := NewLoader(file)
+	current, err := loader.Load()
+	assert.NoError(t, err)
+	watcher := NewWatcher(loader, current)
+	reloaded := make(chan Config, 1)
+	watcher.OnReload(func(c Config) { reloaded <- c })
+	stop := make(chan struct{})
+	defer close(stop)
+
+	assert.NoError(t, watcher.Watch(stop))
+	ioutil.WriteFile(file, []byte("logging:\n  level: warn\n"), 0644)
+
+	select {
+	case c := <-reloaded:
+		assert.Equal(t, "warn", c.Logging.Level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the config wasn't reloaded")
+	}
+}
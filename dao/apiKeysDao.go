@@ -0,0 +1,129 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	API_KEYS_COLLECTION = "api_keys"
+)
+
+const (
+	INVALID_API_KEY_ID_ERROR_MESSAGE = "Invalid API key ID"
+)
+
+type APIKeysDAO interface {
+	FindAll(ctx context.Context) ([]models.APIKey, error)
+	Create(ctx context.Context, key *models.APIKey) error
+	FindByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	Rotate(ctx context.Context, id string, prefix string, hash string) (*models.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type apiKeysDAO struct {
+	db db.DatabaseHelper
+}
+
+func NewAPIKeysDao(db db.DatabaseHelper) APIKeysDAO {
+	return &apiKeysDAO{db: db}
+}
+
+func (kd *apiKeysDAO) FindAll(ctx context.Context) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	cursor, err := kd.db.Collection(API_KEYS_COLLECTION).Find(ctx, bson.D{{}})
+	if err != nil {
+		logging.FromContext(ctx).Error("There was an error finding the api keys::", err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &keys); err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (kd *apiKeysDAO) Create(ctx context.Context, key *models.APIKey) error {
+	_, err := kd.db.Collection(API_KEYS_COLLECTION).InsertOne(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).WithField("name", key.Name).Error("There was an error creating the api key::", err.Error())
+		return err
+	}
+	logging.FromContext(ctx).WithField("name", key.Name).Debug("API key created")
+	return nil
+}
+
+// FindByHash returns the key, revoked or not, with the hash
+func (kd *apiKeysDAO) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := kd.db.Collection(API_KEYS_COLLECTION).FindOne(ctx, bson.M{"hash": hash}).Decode(&key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
+		}
+		logging.FromContext(ctx).Error("There was an error finding the api key::", err.Error())
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Rotate replaces the hash of a key that isn't revoked, the previous key stops working
+func (kd *apiKeysDAO) Rotate(ctx context.Context, id string, prefix string, hash string) (*models.APIKey, error) {
+	objectID, err := apiKeyObjectIDFromHex(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"_id": objectID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"prefix": prefix, "hash": hash, "rotated_at": time.Now().UTC()}}
+	result, err := kd.db.Collection(API_KEYS_COLLECTION).UpdateOne(ctx, filter, update)
+	if err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error rotating the api key::", err.Error())
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	logging.FromContext(ctx).WithField("id", id).Info("API key rotated")
+	var key models.APIKey
+	if err := kd.db.Collection(API_KEYS_COLLECTION).FindOne(ctx, bson.M{"_id": objectID}).Decode(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Revoke disables a key for good, it is kept to be listed
+func (kd *apiKeysDAO) Revoke(ctx context.Context, id string) error {
+	objectID, err := apiKeyObjectIDFromHex(ctx, id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objectID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}}
+	result, err := kd.db.Collection(API_KEYS_COLLECTION).UpdateOne(ctx, filter, update)
+	if err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error revoking the api key::", err.Error())
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	logging.FromContext(ctx).WithField("id", id).Info("API key revoked")
+	return nil
+}
+
+func apiKeyObjectIDFromHex(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, errors.New(INVALID_API_KEY_ID_ERROR_MESSAGE)
+	}
+	return &objectID, nil
+}
@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_apiKeysDAO(t *testing.T) {
+	for name, backend := range planetsBackends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			keys := NewAPIKeysDao(backend(t))
+			key := &models.APIKey{
+				ID: primitive.NewObjectID(), Name: "ci", Prefix: "swapi_abc", Hash: "hash-1",
+				Scopes: []string{"planets:read"}, CreatedAt: time.Now().UTC(),
+			}
+			assert.NoError(t, keys.Create(ctx, key))
+
+			found, err := keys.FindByHash(ctx, "hash-1")
+			assert.NoError(t, err)
+			assert.Equal(t, "ci", found.Name)
+			assert.Equal(t, []string{"planets:read"}, found.Scopes)
+
+			rotated, err := keys.Rotate(ctx, key.ID.Hex(), "swapi_def", "hash-2")
+			assert.NoError(t, err)
+			assert.Equal(t, "swapi_def", rotated.Prefix)
+			assert.NotNil(t, rotated.RotatedAt)
+			_, err = keys.FindByHash(ctx, "hash-1")
+			assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+
+			assert.NoError(t, keys.Revoke(ctx, key.ID.Hex()))
+			assert.EqualError(t, keys.Revoke(ctx, key.ID.Hex()), NOT_FOUND_ERROR_MESSAGE)
+			_, err = keys.Rotate(ctx, key.ID.Hex(), "swapi_ghi", "hash-3")
+			assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+			revoked, err := keys.FindByHash(ctx, "hash-2")
+			assert.NoError(t, err)
+			assert.NotNil(t, revoked.RevokedAt)
+
+			all, err := keys.FindAll(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, all, 1)
+			assert.EqualError(t, keys.Revoke(ctx, "12345"), INVALID_API_KEY_ID_ERROR_MESSAGE)
+		})
+	}
+}
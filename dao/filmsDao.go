@@ -0,0 +1,164 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	FILMS_COLLECTION = "films"
+)
+
+const (
+	INVALID_FILM_ID_ERROR_MESSAGE = "Invalid Film ID"
+)
+
+type FilmsDAO interface {
+	FindAll(ctx context.Context) ([]models.Film, error)
+	Create(ctx context.Context, film *models.Film) error
+	FindByID(ctx context.Context, id string) (*models.Film, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Film, error)
+	Update(ctx context.Context, id string, film *models.Film) error
+	Delete(ctx context.Context, id string) error
+	UpsertByEpisode(ctx context.Context, film *models.Film) error
+}
+
+type filmsDAO struct {
+	db db.DatabaseHelper
+}
+
+func NewFilmsDao(db db.DatabaseHelper) FilmsDAO {
+	return &filmsDAO{db: db}
+}
+
+func (fd *filmsDAO) FindAll(ctx context.Context) ([]models.Film, error) {
+	filter := bson.D{{}}
+	return fd.find(ctx, filter)
+}
+
+func (fd *filmsDAO) Create(ctx context.Context, film *models.Film) error {
+	_, err := fd.db.Collection(FILMS_COLLECTION).InsertOne(ctx, film)
+	if err != nil {
+		logging.FromContext(ctx).WithField("title", film.Title).Error("There was an error creating the film::", err.Error())
+		return err
+	}
+	logging.FromContext(ctx).WithField("title", film.Title).Debug("Film created")
+	return nil
+}
+
+func (fd *filmsDAO) FindByID(ctx context.Context, id string) (*models.Film, error) {
+	objectID, err := filmObjectIDFromHex(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var film models.Film
+	filter := bson.M{"_id": objectID}
+	if err := fd.db.Collection(FILMS_COLLECTION).FindOne(ctx, filter).Decode(&film); err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error find the film by id")
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
+		}
+		return nil, err
+	}
+	return &film, nil
+}
+
+func (fd *filmsDAO) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Film, error) {
+	if len(ids) == 0 {
+		return []models.Film{}, nil
+	}
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	return fd.find(ctx, filter)
+}
+
+func (fd *filmsDAO) Update(ctx context.Context, id string, film *models.Film) error {
+	objectID, err := filmObjectIDFromHex(ctx, id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{
+		"title":        film.Title,
+		"episode_id":   film.EpisodeID,
+		"director":     film.Director,
+		"release_date": film.ReleaseDate,
+	}}
+	result, err := fd.db.Collection(FILMS_COLLECTION).UpdateOne(ctx, filter, update)
+	if err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error updating the film::", err.Error())
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	film.ID = *objectID
+	logging.FromContext(ctx).WithField("id", id).Debug("Film updated")
+	return nil
+}
+
+func (fd *filmsDAO) Delete(ctx context.Context, id string) error {
+	objectID, err := filmObjectIDFromHex(ctx, id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objectID}
+
+	result, err := fd.db.Collection(FILMS_COLLECTION).DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	logging.FromContext(ctx).Debug("Film removed")
+	return nil
+}
+
+// UpsertByEpisode replaces the film with the same episode id, or creates it
+func (fd *filmsDAO) UpsertByEpisode(ctx context.Context, film *models.Film) error {
+	var existing models.Film
+	err := fd.db.Collection(FILMS_COLLECTION).FindOne(ctx, bson.M{"episode_id": film.EpisodeID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		if film.ID.IsZero() {
+			film.ID = db.ObjectID().NewObjectID()
+		}
+		return fd.Create(ctx, film)
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("episode", film.EpisodeID).Error("There was an error finding the film by episode::", err.Error())
+		return err
+	}
+	return fd.Update(ctx, existing.ID.Hex(), film)
+}
+
+func (fd *filmsDAO) find(ctx context.Context, filter interface{}) ([]models.Film, error) {
+	films := []models.Film{}
+	cursor, err := fd.db.Collection(FILMS_COLLECTION).Find(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx).WithField("filter", filter).Error("There was an error finding the films::", err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &films); err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	return films, nil
+}
+
+func filmObjectIDFromHex(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	idPrimitive, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, errors.New(INVALID_FILM_ID_ERROR_MESSAGE)
+	}
+
+	return &idPrimitive, nil
+}
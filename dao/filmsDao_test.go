@@ -0,0 +1,184 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_filmsDAO_FindAll(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	expected := []models.Film{{Title: "A New Hope", EpisodeID: 4}}
+
+	dbHelper.
+		On("Collection", "films").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Find", context.Background(), primitive.D{{}}).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.Film)
+			*arg = expected
+		}).
+		Return(nil)
+
+	dao := NewFilmsDao(dbHelper)
+	films, err := dao.FindAll(context.Background())
+
+	assert.Equal(t, expected, films)
+	assert.NoError(t, err)
+}
+
+func Test_filmsDAO_Create(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	collectionHelper.
+		On("InsertOne", context.Background(), &models.Film{Title: "mocked-film"}).
+		Once().
+		Return(nil, nil)
+
+	dbHelper.
+		On("Collection", "films").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewFilmsDao(dbHelper)
+
+	err := dao.Create(context.Background(), &models.Film{Title: "mocked-film"})
+	assert.NoError(t, err)
+}
+
+func Test_filmsDAO_FindByID_with_not_found(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	srHelper := &mocks.SingleResultHelper{}
+
+	srHelper.
+		On("Decode", mock.AnythingOfType("*models.Film")).
+		Once().
+		Return(mongo.ErrNoDocuments)
+
+	collectionHelper.
+		On("FindOne", context.Background(), mock.Anything).
+		Once().
+		Return(srHelper)
+
+	dbHelper.
+		On("Collection", "films").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewFilmsDao(dbHelper)
+
+	film, err := dao.FindByID(context.Background(), "5e27096d0c326694932a4cc8")
+	assert.Nil(t, film)
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+}
+
+func Test_filmsDAO_FindByID_with_invalid_id_error(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+
+	dao := NewFilmsDao(dbHelper)
+
+	film, err := dao.FindByID(context.Background(), "invalid id")
+	assert.Nil(t, film)
+	assert.EqualError(t, err, INVALID_FILM_ID_ERROR_MESSAGE)
+}
+
+func Test_filmsDAO_FindByIDs_without_ids(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+
+	dao := NewFilmsDao(dbHelper)
+
+	films, err := dao.FindByIDs(context.Background(), nil)
+	assert.Equal(t, []models.Film{}, films)
+	assert.NoError(t, err)
+}
+
+func Test_filmsDAO_Update(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), mock.Anything, mock.Anything).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	dbHelper.
+		On("Collection", "films").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewFilmsDao(dbHelper)
+
+	id := "5e27096d0c326694932a4cc8"
+	film := &models.Film{Title: "mocked-film"}
+	err := dao.Update(context.Background(), id, film)
+	assert.NoError(t, err)
+	assert.Equal(t, id, film.ID.Hex())
+}
+
+func Test_filmsDAO_Update_with_not_found(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), mock.Anything, mock.Anything).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	dbHelper.
+		On("Collection", "films").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewFilmsDao(dbHelper)
+
+	err := dao.Update(context.Background(), "5e27096d0c326694932a4cc8", &models.Film{})
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+}
+
+func Test_filmsDAO_Delete_with_db_error(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	collectionHelper.
+		On("DeleteOne", context.Background(), mock.Anything).
+		Once().
+		Return(nil, errors.New("mocked-db-error"))
+
+	dbHelper.
+		On("Collection", "films").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewFilmsDao(dbHelper)
+
+	err := dao.Delete(context.Background(), "5e27096d0c326694932a4cc8")
+	assert.EqualError(t, err, "mocked-db-error")
+}
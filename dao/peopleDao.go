@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	PEOPLE_COLLECTION = "people"
+)
+
+const (
+	INVALID_PERSON_ID_ERROR_MESSAGE = "Invalid Person ID"
+)
+
+type PeopleDAO interface {
+	FindAll(ctx context.Context) ([]models.Person, error)
+	Create(ctx context.Context, person *models.Person) error
+	FindByID(ctx context.Context, id string) (*models.Person, error)
+	FindByName(ctx context.Context, name string) ([]models.Person, error)
+	FindByHomeworld(ctx context.Context, planetID string) ([]models.Person, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type peopleDAO struct {
+	db db.DatabaseHelper
+}
+
+func NewPeopleDao(db db.DatabaseHelper) PeopleDAO {
+	return &peopleDAO{db: db}
+}
+
+func (pd *peopleDAO) FindAll(ctx context.Context) ([]models.Person, error) {
+	filter := bson.D{{}}
+	return pd.find(ctx, filter)
+}
+
+func (pd *peopleDAO) Create(ctx context.Context, person *models.Person) error {
+	_, err := pd.db.Collection(PEOPLE_COLLECTION).InsertOne(ctx, person)
+	if err != nil {
+		logging.FromContext(ctx).WithField("name", person.Name).Error("There was an error creating the person::", err.Error())
+		return err
+	}
+	logging.FromContext(ctx).WithField("name", person.Name).Debug("Person created")
+	return nil
+}
+
+func (pd *peopleDAO) FindByID(ctx context.Context, id string) (*models.Person, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error(err)
+		return nil, errors.New(INVALID_PERSON_ID_ERROR_MESSAGE)
+	}
+	var person models.Person
+	filter := bson.M{"_id": objectID}
+	if err := pd.db.Collection(PEOPLE_COLLECTION).FindOne(ctx, filter).Decode(&person); err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error find the person by id")
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
+		}
+		return nil, err
+	}
+	return &person, nil
+}
+
+func (pd *peopleDAO) FindByName(ctx context.Context, name string) ([]models.Person, error) {
+	filter := bson.D{{Key: "name", Value: primitive.Regex{Pattern: name, Options: "i"}}}
+	return pd.find(ctx, filter)
+}
+
+func (pd *peopleDAO) FindByHomeworld(ctx context.Context, planetID string) ([]models.Person, error) {
+	objectID, err := createObjectIDFromHex(ctx, planetID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"homeworld": objectID}
+	return pd.find(ctx, filter)
+}
+
+func (pd *peopleDAO) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error(err)
+		return errors.New(INVALID_PERSON_ID_ERROR_MESSAGE)
+	}
+	filter := bson.M{"_id": objectID}
+
+	result, err := pd.db.Collection(PEOPLE_COLLECTION).DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	logging.FromContext(ctx).Debug("Person removed")
+	return nil
+}
+
+func (pd *peopleDAO) find(ctx context.Context, filter interface{}) ([]models.Person, error) {
+	people := []models.Person{}
+	cursor, err := pd.db.Collection(PEOPLE_COLLECTION).Find(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx).WithField("filter", filter).Error("There was an error finding the people::", err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &people); err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	return people, nil
+}
@@ -0,0 +1,134 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_peopleDAO_FindAll(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	expected := []models.Person{{Name: "Luke Skywalker"}}
+
+	dbHelper.
+		On("Collection", "people").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Find", context.Background(), primitive.D{{}}).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.Person)
+			*arg = expected
+		}).
+		Return(nil)
+
+	dao := NewPeopleDao(dbHelper)
+	people, err := dao.FindAll(context.Background())
+
+	assert.Equal(t, expected, people)
+	assert.NoError(t, err)
+}
+
+func Test_peopleDAO_Create(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	collectionHelper.
+		On("InsertOne", context.Background(), &models.Person{Name: "mocked-person"}).
+		Once().
+		Return(nil, nil)
+
+	dbHelper.
+		On("Collection", "people").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewPeopleDao(dbHelper)
+
+	err := dao.Create(context.Background(), &models.Person{Name: "mocked-person"})
+	assert.NoError(t, err)
+}
+
+func Test_peopleDAO_FindByID_with_invalid_id_error(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+
+	dao := NewPeopleDao(dbHelper)
+
+	person, err := dao.FindByID(context.Background(), "invalid id")
+	assert.Nil(t, person)
+	assert.EqualError(t, err, INVALID_PERSON_ID_ERROR_MESSAGE)
+}
+
+func Test_peopleDAO_FindByHomeworld(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	planetID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+	expected := []models.Person{{Name: "Leia Organa", Homeworld: &planetID}}
+
+	dbHelper.
+		On("Collection", "people").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Find", context.Background(), bson.M{"homeworld": &planetID}).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.Person)
+			*arg = expected
+		}).
+		Return(nil)
+
+	dao := NewPeopleDao(dbHelper)
+	people, err := dao.FindByHomeworld(context.Background(), planetID.Hex())
+
+	assert.Equal(t, expected, people)
+	assert.NoError(t, err)
+}
+
+func Test_peopleDAO_Delete_with_notFound_error(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	collectionHelper.
+		On("DeleteOne", context.Background(), mock.Anything).
+		Once().
+		Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+
+	dbHelper.
+		On("Collection", "people").
+		Once().
+		Return(collectionHelper)
+
+	dao := NewPeopleDao(dbHelper)
+
+	err := dao.Delete(context.Background(), "5e27096d0c326694932a4cc8")
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+}
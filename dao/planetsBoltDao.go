@@ -0,0 +1,516 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NAME_INDEX = COLLECTION + ".name"
+)
+
+const (
+	DUPLICATE_PLANET_ERROR_MESSAGE = "Planet already exists"
+)
+
+// planetsBoltDAO stores the planets in a bolt bucket keyed by id, with a second
+// bucket indexing them by lower cased name. Every write updates both buckets in
+// the same transaction.
+type planetsBoltDAO struct {
+	store   *bbolt.DB
+	planets []byte
+	names   []byte
+	people  PeopleDAO
+}
+
+// NewPlanetsBoltDao returns the planets DAO of a bolt database, the residents
+// are read through the people DAO
+func NewPlanetsBoltDao(database db.BoltDatabaseHelper, people PeopleDAO) PlanetsDAO {
+	return &planetsBoltDAO{
+		store:   database.Store(),
+		planets: database.Bucket(COLLECTION),
+		names:   database.Bucket(NAME_INDEX),
+		people:  people,
+	}
+}
+
+func (pd *planetsBoltDAO) FindAll(ctx context.Context) ([]models.Planet, error) {
+	return pd.filter(ctx, func(planet *models.Planet) bool { return true })
+}
+
+func (pd *planetsBoltDAO) Create(ctx context.Context, planet *models.Planet) error {
+	// the films count is derived from the linked films, never from client input
+	planet.Films = len(planet.FilmIDs)
+	if planet.ID.IsZero() {
+		planet.ID = db.ObjectID().NewObjectID()
+	}
+	err := pd.store.Update(func(tx *bbolt.Tx) error {
+		planets, err := tx.CreateBucketIfNotExists(pd.planets)
+		if err != nil {
+			return err
+		}
+		if planets.Get(planet.ID[:]) != nil {
+			return errors.New(DUPLICATE_PLANET_ERROR_MESSAGE)
+		}
+		return pd.put(tx, planet, nil)
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithField("name", planet.Name).Error("There was an error creating the planet::", err.Error())
+		return err
+	}
+	logging.FromContext(ctx).WithField("name", planet.Name).Debug("Planet created")
+	return nil
+}
+
+func (pd *planetsBoltDAO) FindByID(ctx context.Context, id string) (*models.Planet, error) {
+	objectID, err := createObjectIDFromHex(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error find the planet by id")
+		return nil, err
+	}
+	var planet *models.Planet
+	err = pd.store.View(func(tx *bbolt.Tx) error {
+		planet, err = pd.get(tx, *objectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if planet == nil {
+		return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	return planet, nil
+}
+
+// FindByName matches the name index instead of decoding every planet
+func (pd *planetsBoltDAO) FindByName(ctx context.Context, name string) ([]models.Planet, error) {
+	pattern, err := regexp.Compile("(?i)" + name)
+	if err != nil {
+		return nil, err
+	}
+	planets := []models.Planet{}
+	err = pd.store.View(func(tx *bbolt.Tx) error {
+		return pd.scanNames(tx, nil, func(indexed string, id primitive.ObjectID) error {
+			if !pattern.MatchString(indexed) {
+				return nil
+			}
+			planet, err := pd.get(tx, id)
+			if err != nil || planet == nil {
+				return err
+			}
+			planets = append(planets, *planet)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return planets, nil
+}
+
+func (pd *planetsBoltDAO) Delete(ctx context.Context, id string) error {
+	objectID, err := createObjectIDFromHex(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = pd.store.Update(func(tx *bbolt.Tx) error {
+		planet, err := pd.get(tx, *objectID)
+		if err != nil {
+			return err
+		}
+		if planet == nil {
+			return errors.New(NOT_FOUND_ERROR_MESSAGE)
+		}
+		if err := tx.Bucket(pd.names).Delete(nameKey(planet)); err != nil {
+			return err
+		}
+		return tx.Bucket(pd.planets).Delete(planet.ID[:])
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("Planet removed")
+	return nil
+}
+
+// UpsertByName replaces the planet with the exact same name, or creates it
+func (pd *planetsBoltDAO) UpsertByName(ctx context.Context, planet *models.Planet) error {
+	planet.Films = len(planet.FilmIDs)
+	err := pd.store.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(pd.planets); err != nil {
+			return err
+		}
+		var existing *models.Planet
+		prefix := []byte(strings.ToLower(planet.Name) + "\x00")
+		err := pd.scanNames(tx, prefix, func(indexed string, id primitive.ObjectID) error {
+			found, err := pd.get(tx, id)
+			if err == nil && found != nil && found.Name == planet.Name && existing == nil {
+				existing = found
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			if planet.ID.IsZero() {
+				planet.ID = db.ObjectID().NewObjectID()
+			}
+			return pd.put(tx, planet, nil)
+		}
+		planet.ID = existing.ID
+		return pd.put(tx, planet, existing)
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithField("name", planet.Name).Error("There was an error upserting the planet::", err.Error())
+		return err
+	}
+	logging.FromContext(ctx).WithField("name", planet.Name).Debug("Planet upserted")
+	return nil
+}
+
+func (pd *planetsBoltDAO) FindByFilm(ctx context.Context, filmID string) ([]models.Planet, error) {
+	objectID, err := filmObjectIDFromHex(ctx, filmID)
+	if err != nil {
+		return nil, err
+	}
+	return pd.filter(ctx, func(planet *models.Planet) bool { return hasFilm(planet, *objectID) })
+}
+
+func (pd *planetsBoltDAO) AddFilm(ctx context.Context, id string, filmID string) error {
+	return pd.updateFilms(ctx, id, filmID, func(planet *models.Planet, filmID primitive.ObjectID) error {
+		if !hasFilm(planet, filmID) {
+			planet.FilmIDs = append(planet.FilmIDs, filmID)
+		}
+		return nil
+	})
+}
+
+func (pd *planetsBoltDAO) RemoveFilm(ctx context.Context, id string, filmID string) error {
+	return pd.updateFilms(ctx, id, filmID, func(planet *models.Planet, filmID primitive.ObjectID) error {
+		if !hasFilm(planet, filmID) {
+			return errors.New(NOT_FOUND_ERROR_MESSAGE)
+		}
+		planet.FilmIDs = withoutFilm(planet.FilmIDs, filmID)
+		return nil
+	})
+}
+
+func (pd *planetsBoltDAO) UnlinkFilm(ctx context.Context, filmID string) error {
+	objectID, err := filmObjectIDFromHex(ctx, filmID)
+	if err != nil {
+		return err
+	}
+	unlinked := 0
+	err = pd.store.Update(func(tx *bbolt.Tx) error {
+		planets, err := pd.all(tx)
+		if err != nil {
+			return err
+		}
+		for i := range planets {
+			planet := &planets[i]
+			if !hasFilm(planet, *objectID) {
+				continue
+			}
+			previous := *planet
+			planet.FilmIDs = withoutFilm(planet.FilmIDs, *objectID)
+			planet.Films = len(planet.FilmIDs)
+			if err := pd.put(tx, planet, &previous); err != nil {
+				return err
+			}
+			unlinked++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("film", filmID).Debug("Film unlinked from ", unlinked, " planets")
+	return nil
+}
+
+func (pd *planetsBoltDAO) FindAllWithResidents(ctx context.Context) ([]models.ExpandedPlanet, error) {
+	planets, err := pd.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pd.withResidents(ctx, planets)
+}
+
+func (pd *planetsBoltDAO) FindByIDWithResidents(ctx context.Context, id string) (*models.ExpandedPlanet, error) {
+	planet, err := pd.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := pd.withResidents(ctx, []models.Planet{*planet})
+	if err != nil {
+		return nil, err
+	}
+	return &expanded[0], nil
+}
+
+func (pd *planetsBoltDAO) FindByNameWithResidents(ctx context.Context, name string) ([]models.ExpandedPlanet, error) {
+	planets, err := pd.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return pd.withResidents(ctx, planets)
+}
+
+func (pd *planetsBoltDAO) Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (*models.PlanetStats, error) {
+	if groupBy == "" {
+		groupBy = GROUP_BY_CLIMATE
+	}
+	if _, err := groupByStages(groupBy); err != nil {
+		return nil, err
+	}
+	var name *regexp.Regexp
+	if filter.Name != "" {
+		var err error
+		if name, err = regexp.Compile("(?i)" + filter.Name); err != nil {
+			return nil, err
+		}
+	}
+	planets, err := pd.filter(ctx, func(planet *models.Planet) bool {
+		return matchesStatsFilter(planet, name, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.PlanetStats{GroupBy: groupBy, Groups: []models.StatsGroup{}}
+	counts := map[interface{}]int{}
+	sum := 0
+	for i, planet := range planets {
+		sum += planet.Films
+		if i == 0 || planet.Films < stats.Films.Min {
+			stats.Films.Min = planet.Films
+		}
+		if i == 0 || planet.Films > stats.Films.Max {
+			stats.Films.Max = planet.Films
+		}
+		switch groupBy {
+		case GROUP_BY_CLIMATE:
+			countValues(counts, planet.Climate)
+		case GROUP_BY_TERRAIN:
+			countValues(counts, planet.Terrain)
+		case GROUP_BY_FILMS:
+			counts[planet.Films]++
+		}
+	}
+	if stats.Total = len(planets); stats.Total > 0 {
+		stats.Films.Avg = float64(sum) / float64(stats.Total)
+	}
+	for value, count := range counts {
+		stats.Groups = append(stats.Groups, models.StatsGroup{Value: value, Count: count})
+	}
+	sort.Slice(stats.Groups, func(i, j int) bool {
+		a, b := stats.Groups[i], stats.Groups[j]
+		if groupBy == GROUP_BY_FILMS {
+			return a.Value.(int) < b.Value.(int)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value.(string) < b.Value.(string)
+	})
+	return stats, nil
+}
+
+func matchesStatsFilter(planet *models.Planet, name *regexp.Regexp, filter models.StatsFilter) bool {
+	if name != nil && !name.MatchString(planet.Name) {
+		return false
+	}
+	if filter.Climate != "" && !containsValue(planet.Climate, models.NormalizeListValue(filter.Climate)) {
+		return false
+	}
+	if filter.Terrain != "" && !containsValue(planet.Terrain, models.NormalizeListValue(filter.Terrain)) {
+		return false
+	}
+	if filter.MinFilms != nil && planet.Films < *filter.MinFilms {
+		return false
+	}
+	return filter.MaxFilms == nil || planet.Films <= *filter.MaxFilms
+}
+
+func containsValue(values models.StringList, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func countValues(counts map[interface{}]int, values models.StringList) {
+	for _, value := range values {
+		counts[value]++
+	}
+}
+
+func hasFilm(planet *models.Planet, filmID primitive.ObjectID) bool {
+	for _, linked := range planet.FilmIDs {
+		if linked == filmID {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFilms changes the films linked to a planet, keeping its films count in sync
+func (pd *planetsBoltDAO) updateFilms(ctx context.Context, id string, filmID string, change func(*models.Planet, primitive.ObjectID) error) error {
+	filmObjectID, err := filmObjectIDFromHex(ctx, filmID)
+	if err != nil {
+		return err
+	}
+	objectID, err := createObjectIDFromHex(ctx, id)
+	if err != nil {
+		return err
+	}
+	return pd.store.Update(func(tx *bbolt.Tx) error {
+		planet, err := pd.get(tx, *objectID)
+		if err != nil {
+			return err
+		}
+		if planet == nil {
+			return errors.New(NOT_FOUND_ERROR_MESSAGE)
+		}
+		previous := *planet
+		if err := change(planet, *filmObjectID); err != nil {
+			return err
+		}
+		planet.Films = len(planet.FilmIDs)
+		return pd.put(tx, planet, &previous)
+	})
+}
+
+func (pd *planetsBoltDAO) withResidents(ctx context.Context, planets []models.Planet) ([]models.ExpandedPlanet, error) {
+	people, err := pd.people.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	residents := map[primitive.ObjectID][]models.ResidentSummary{}
+	for _, person := range people {
+		if person.Homeworld != nil {
+			residents[*person.Homeworld] = append(residents[*person.Homeworld], models.ResidentSummary{ID: person.ID, Name: person.Name})
+		}
+	}
+	expanded := []models.ExpandedPlanet{}
+	for _, planet := range planets {
+		summaries := residents[planet.ID]
+		if summaries == nil {
+			summaries = []models.ResidentSummary{}
+		}
+		expanded = append(expanded, models.ExpandedPlanet{Planet: planet, Residents: summaries})
+	}
+	return expanded, nil
+}
+
+func (pd *planetsBoltDAO) filter(ctx context.Context, match func(*models.Planet) bool) ([]models.Planet, error) {
+	var planets []models.Planet
+	err := pd.store.View(func(tx *bbolt.Tx) error {
+		var err error
+		planets, err = pd.all(tx)
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("There was an error finding the planets::", err.Error())
+		return nil, err
+	}
+	result := []models.Planet{}
+	for i := range planets {
+		if match(&planets[i]) {
+			result = append(result, planets[i])
+		}
+	}
+	return result, nil
+}
+
+func (pd *planetsBoltDAO) all(tx *bbolt.Tx) ([]models.Planet, error) {
+	planets := []models.Planet{}
+	bucket := tx.Bucket(pd.planets)
+	if bucket == nil {
+		return planets, nil
+	}
+	err := bucket.ForEach(func(k, v []byte) error {
+		var planet models.Planet
+		if err := bson.Unmarshal(v, &planet); err != nil {
+			return err
+		}
+		planets = append(planets, planet)
+		return nil
+	})
+	return planets, err
+}
+
+// get returns the planet stored with the id, or nil when there is none
+func (pd *planetsBoltDAO) get(tx *bbolt.Tx, id primitive.ObjectID) (*models.Planet, error) {
+	bucket := tx.Bucket(pd.planets)
+	if bucket == nil {
+		return nil, nil
+	}
+	data := bucket.Get(id[:])
+	if data == nil {
+		return nil, nil
+	}
+	var planet models.Planet
+	if err := bson.Unmarshal(data, &planet); err != nil {
+		return nil, err
+	}
+	return &planet, nil
+}
+
+// put writes the planet and its name index entry, replacing the entry of the previous version
+func (pd *planetsBoltDAO) put(tx *bbolt.Tx, planet *models.Planet, previous *models.Planet) error {
+	data, err := bson.Marshal(planet)
+	if err != nil {
+		return err
+	}
+	names, err := tx.CreateBucketIfNotExists(pd.names)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if err := names.Delete(nameKey(previous)); err != nil {
+			return err
+		}
+	}
+	if err := names.Put(nameKey(planet), []byte{}); err != nil {
+		return err
+	}
+	return tx.Bucket(pd.planets).Put(planet.ID[:], data)
+}
+
+// scanNames walks the name index in order, only over the keys starting with prefix
+func (pd *planetsBoltDAO) scanNames(tx *bbolt.Tx, prefix []byte, fn func(name string, id primitive.ObjectID) error) error {
+	bucket := tx.Bucket(pd.names)
+	if bucket == nil {
+		return nil
+	}
+	cursor := bucket.Cursor()
+	for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+		var id primitive.ObjectID
+		copy(id[:], k[len(k)-len(id):])
+		if err := fn(string(k[:len(k)-len(id)-1]), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nameKey is the index key of a planet: its lower cased name, a separator and its id
+func nameKey(planet *models.Planet) []byte {
+	key := []byte(strings.ToLower(planet.Name) + "\x00")
+	return append(key, planet.ID[:]...)
+}
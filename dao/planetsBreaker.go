@@ -0,0 +1,199 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wallacebenevides/star-wars-api/breaker"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	DUPLICATE_KEY_CODE = 11000
+)
+
+// breakerPlanetsDAO fails fast with a *breaker.OpenError while the database
+// keeps failing, instead of waiting for the driver timeouts
+type breakerPlanetsDAO struct {
+	next    PlanetsDAO
+	breaker *breaker.Breaker
+}
+
+func NewBreakerPlanetsDao(next PlanetsDAO, b *breaker.Breaker) PlanetsDAO {
+	return &breakerPlanetsDAO{next: next, breaker: b}
+}
+
+// allow reserves a call, the returned function reports its error to the breaker
+func (pd *breakerPlanetsDAO) allow() (func(err error), error) {
+	done, err := pd.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+	return func(err error) { done(isFailure(err)) }, nil
+}
+
+// isFailure tells the errors of the database from the ones of the caller,
+// e.g. an invalid id, a missing planet or a canceled request
+func isFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, mongo.ErrNoDocuments) {
+		return false
+	}
+	switch err.Error() {
+	case NOT_FOUND_ERROR_MESSAGE,
+		INVALID_ID_ERROR_MESSAGE,
+		INVALID_FILM_ID_ERROR_MESSAGE,
+		INVALID_GROUP_BY_ERROR_MESSAGE,
+		DUPLICATE_PLANET_ERROR_MESSAGE:
+		return false
+	}
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if e.Code == DUPLICATE_KEY_CODE {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (pd *breakerPlanetsDAO) FindAll(ctx context.Context) ([]models.Planet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindAll(ctx)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) Create(ctx context.Context, planet *models.Planet) error {
+	finish, err := pd.allow()
+	if err != nil {
+		return err
+	}
+	err = pd.next.Create(ctx, planet)
+	finish(err)
+	return err
+}
+
+func (pd *breakerPlanetsDAO) FindByID(ctx context.Context, id string) (*models.Planet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindByID(ctx, id)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) FindByName(ctx context.Context, name string) ([]models.Planet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindByName(ctx, name)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) Delete(ctx context.Context, id string) error {
+	finish, err := pd.allow()
+	if err != nil {
+		return err
+	}
+	err = pd.next.Delete(ctx, id)
+	finish(err)
+	return err
+}
+
+func (pd *breakerPlanetsDAO) Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (*models.PlanetStats, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.Stats(ctx, groupBy, filter)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) FindByFilm(ctx context.Context, filmID string) ([]models.Planet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindByFilm(ctx, filmID)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) AddFilm(ctx context.Context, id string, filmID string) error {
+	finish, err := pd.allow()
+	if err != nil {
+		return err
+	}
+	err = pd.next.AddFilm(ctx, id, filmID)
+	finish(err)
+	return err
+}
+
+func (pd *breakerPlanetsDAO) RemoveFilm(ctx context.Context, id string, filmID string) error {
+	finish, err := pd.allow()
+	if err != nil {
+		return err
+	}
+	err = pd.next.RemoveFilm(ctx, id, filmID)
+	finish(err)
+	return err
+}
+
+func (pd *breakerPlanetsDAO) UnlinkFilm(ctx context.Context, filmID string) error {
+	finish, err := pd.allow()
+	if err != nil {
+		return err
+	}
+	err = pd.next.UnlinkFilm(ctx, filmID)
+	finish(err)
+	return err
+}
+
+func (pd *breakerPlanetsDAO) FindAllWithResidents(ctx context.Context) ([]models.ExpandedPlanet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindAllWithResidents(ctx)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) FindByIDWithResidents(ctx context.Context, id string) (*models.ExpandedPlanet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindByIDWithResidents(ctx, id)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) FindByNameWithResidents(ctx context.Context, name string) ([]models.ExpandedPlanet, error) {
+	finish, err := pd.allow()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pd.next.FindByNameWithResidents(ctx, name)
+	finish(err)
+	return result, err
+}
+
+func (pd *breakerPlanetsDAO) UpsertByName(ctx context.Context, planet *models.Planet) error {
+	finish, err := pd.allow()
+	if err != nil {
+		return err
+	}
+	err = pd.next.UpsertByName(ctx, planet)
+	finish(err)
+	return err
+}
@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/breaker"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_breakerPlanetsDAO(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("FindByID", mock.Anything, "12345").Return(nil, errors.New(INVALID_ID_ERROR_MESSAGE))
+	next.On("FindAll", mock.Anything).Times(2).Return(nil, errors.New("server selection timeout"))
+	b := breaker.New("planets_test", config.Breaker{
+		Enabled: true, Window: time.Minute, MinRequests: 4, FailureRate: 0.5, OpenTimeout: time.Minute, HalfOpenRequests: 1,
+	})
+	dao := NewBreakerPlanetsDao(next, b)
+
+	// the errors of the caller don't count
+	for i := 0; i < 2; i++ {
+		_, err := dao.FindByID(context.Background(), "12345")
+		assert.EqualError(t, err, INVALID_ID_ERROR_MESSAGE)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := dao.FindAll(context.Background())
+		assert.Error(t, err)
+	}
+	assert.Equal(t, breaker.STATE_OPEN, b.State())
+
+	_, err := dao.FindAll(context.Background())
+	assert.IsType(t, &breaker.OpenError{}, err)
+	assert.Error(t, dao.Create(context.Background(), &models.Planet{}))
+	next.AssertExpectations(t)
+}
+
+func Test_isFailure(t *testing.T) {
+	assert.False(t, isFailure(nil))
+	assert.False(t, isFailure(context.Canceled))
+	assert.False(t, isFailure(errors.New(NOT_FOUND_ERROR_MESSAGE)))
+	assert.False(t, isFailure(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: DUPLICATE_KEY_CODE}}}))
+	assert.True(t, isFailure(context.DeadlineExceeded))
+	assert.True(t, isFailure(errors.New("connection reset by peer")))
+}
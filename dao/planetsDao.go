@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
 	"github.com/wallacebenevides/star-wars-api/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -27,14 +27,28 @@ type PlanetsDAO interface {
 	FindByID(cxt context.Context, id string) (*models.Planet, error)
 	FindByName(cxt context.Context, name string) ([]models.Planet, error)
 	Delete(cxt context.Context, id string) error
+	Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (*models.PlanetStats, error)
+	FindByFilm(ctx context.Context, filmID string) ([]models.Planet, error)
+	AddFilm(ctx context.Context, id string, filmID string) error
+	RemoveFilm(ctx context.Context, id string, filmID string) error
+	UnlinkFilm(ctx context.Context, filmID string) error
+	FindAllWithResidents(ctx context.Context) ([]models.ExpandedPlanet, error)
+	FindByIDWithResidents(ctx context.Context, id string) (*models.ExpandedPlanet, error)
+	FindByNameWithResidents(ctx context.Context, name string) ([]models.ExpandedPlanet, error)
+	UpsertByName(ctx context.Context, planet *models.Planet) error
 }
 
 type planetsDAO struct {
 	db db.DatabaseHelper
 }
 
-func NewPlanetsDao(db db.DatabaseHelper) PlanetsDAO {
-	return &planetsDAO{db: db}
+// NewPlanetsDao returns the planets DAO of the database, bolt databases get
+// their own implementation with a name index
+func NewPlanetsDao(database db.DatabaseHelper) PlanetsDAO {
+	if bolt, ok := database.(db.BoltDatabaseHelper); ok {
+		return NewPlanetsBoltDao(bolt, NewPeopleDao(database))
+	}
+	return &planetsDAO{db: database}
 }
 
 func (pd *planetsDAO) FindAll(ctx context.Context) ([]models.Planet, error) {
@@ -43,26 +57,28 @@ func (pd *planetsDAO) FindAll(ctx context.Context) ([]models.Planet, error) {
 }
 
 func (pd *planetsDAO) Create(ctx context.Context, planet *models.Planet) error {
+	// the films count is derived from the linked films, never from client input
+	planet.Films = len(planet.FilmIDs)
 	_, err := pd.db.Collection(COLLECTION).InsertOne(ctx, planet)
 	if err != nil {
-		log.WithField("name", planet.Name).Error("There was an error creating the planet::", err.Error())
+		logging.FromContext(ctx).WithField("name", planet.Name).Error("There was an error creating the planet::", err.Error())
 		return err
 	}
-	log.WithField("name", planet.Name).Debug("Planet created")
+	logging.FromContext(ctx).WithField("name", planet.Name).Debug("Planet created")
 	return nil
 }
 
 func (pd *planetsDAO) FindByID(ctx context.Context, id string) (*models.Planet, error) {
-	objectID, err := createObjectIDFromHex(id)
+	objectID, err := createObjectIDFromHex(ctx, id)
 	if err != nil {
-		log.WithField("id", id).Error("There was an error find the planet by id")
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error find the planet by id")
 		return nil, err
 	}
 	filter := bson.M{"_id": objectID}
 	planets, err := pd.findOne(ctx, filter)
 
 	if err != nil {
-		log.WithField("id", id).Error("There was an error find the planet by id")
+		logging.FromContext(ctx).WithField("id", id).Error("There was an error find the planet by id")
 		return nil, err
 	}
 
@@ -70,12 +86,12 @@ func (pd *planetsDAO) FindByID(ctx context.Context, id string) (*models.Planet,
 }
 
 func (pd *planetsDAO) FindByName(ctx context.Context, name string) ([]models.Planet, error) {
-	filter := bson.D{{"name", primitive.Regex{Pattern: name, Options: "i"}}}
+	filter := bson.D{{Key: "name", Value: primitive.Regex{Pattern: name, Options: "i"}}}
 	return pd.find(ctx, filter)
 }
 
 func (pd *planetsDAO) Delete(ctx context.Context, id string) error {
-	objectID, err := createObjectIDFromHex(id)
+	objectID, err := createObjectIDFromHex(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -88,20 +104,152 @@ func (pd *planetsDAO) Delete(ctx context.Context, id string) error {
 	if result.DeletedCount == 0 {
 		return errors.New(NOT_FOUND_ERROR_MESSAGE)
 	}
-	log.Debug("Planet removed")
+	logging.FromContext(ctx).Debug("Planet removed")
+	return nil
+}
+
+// UpsertByName replaces the planet with the exact same name, or creates it
+func (pd *planetsDAO) UpsertByName(ctx context.Context, planet *models.Planet) error {
+	var existing models.Planet
+	err := pd.db.Collection(COLLECTION).FindOne(ctx, bson.M{"name": planet.Name}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		if planet.ID.IsZero() {
+			planet.ID = db.ObjectID().NewObjectID()
+		}
+		return pd.Create(ctx, planet)
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("name", planet.Name).Error("There was an error finding the planet by name::", err.Error())
+		return err
+	}
+	planet.ID = existing.ID
+	planet.Films = len(planet.FilmIDs)
+	update := bson.M{"$set": bson.M{
+		"climate":  planet.Climate,
+		"terrain":  planet.Terrain,
+		"film_ids": planet.FilmIDs,
+		"films":    planet.Films,
+	}}
+	if _, err := pd.db.Collection(COLLECTION).UpdateOne(ctx, bson.M{"_id": existing.ID}, update); err != nil {
+		logging.FromContext(ctx).WithField("name", planet.Name).Error("There was an error updating the planet::", err.Error())
+		return err
+	}
+	logging.FromContext(ctx).WithField("name", planet.Name).Debug("Planet updated")
+	return nil
+}
+
+func (pd *planetsDAO) FindByFilm(ctx context.Context, filmID string) ([]models.Planet, error) {
+	objectID, err := filmObjectIDFromHex(ctx, filmID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"film_ids": objectID}
+	return pd.find(ctx, filter)
+}
+
+// AddFilm links the film to the planet, the filter on the links makes the
+// films count change only when the film wasn't linked yet, even when two
+// requests link it at once
+func (pd *planetsDAO) AddFilm(ctx context.Context, id string, filmID string) error {
+	objectID, filmObjectID, err := filmLinkIDs(ctx, id, filmID)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objectID, "film_ids": bson.M{"$ne": filmObjectID}}
+	update := bson.M{"$addToSet": bson.M{"film_ids": filmObjectID}, "$inc": bson.M{"films": 1}}
+	linked, err := pd.updateFilms(ctx, filter, update)
+	if err != nil || linked {
+		return err
+	}
+	// the planet is missing, or the film was already linked
+	_, err = pd.findOne(ctx, bson.M{"_id": objectID})
+	return err
+}
+
+func (pd *planetsDAO) RemoveFilm(ctx context.Context, id string, filmID string) error {
+	objectID, filmObjectID, err := filmLinkIDs(ctx, id, filmID)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": objectID, "film_ids": filmObjectID}
+	update := bson.M{"$pull": bson.M{"film_ids": filmObjectID}, "$inc": bson.M{"films": -1}}
+	removed, err := pd.updateFilms(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	return nil
+}
+
+// UnlinkFilm removes the film from the planets one at a time, each update
+// only matching a planet still linked to it
+func (pd *planetsDAO) UnlinkFilm(ctx context.Context, filmID string) error {
+	filmObjectID, err := filmObjectIDFromHex(ctx, filmID)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"film_ids": filmObjectID}
+	update := bson.M{"$pull": bson.M{"film_ids": filmObjectID}, "$inc": bson.M{"films": -1}}
+	unlinked := 0
+	for {
+		removed, err := pd.updateFilms(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			break
+		}
+		unlinked++
+	}
+	logging.FromContext(ctx).WithField("film", filmID).Debug("Film unlinked from ", unlinked, " planets")
 	return nil
 }
 
+func filmLinkIDs(ctx context.Context, id string, filmID string) (*primitive.ObjectID, *primitive.ObjectID, error) {
+	filmObjectID, err := filmObjectIDFromHex(ctx, filmID)
+	if err != nil {
+		return nil, nil, err
+	}
+	objectID, err := createObjectIDFromHex(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return objectID, filmObjectID, nil
+}
+
+// updateFilms updates the films linked to the planet matching the filter,
+// telling whether one matched
+func (pd *planetsDAO) updateFilms(ctx context.Context, filter bson.M, update bson.M) (bool, error) {
+	result, err := pd.db.Collection(COLLECTION).UpdateOne(ctx, filter, update)
+	if err != nil {
+		logging.FromContext(ctx).WithField("filter", filter).Error("There was an error updating the planet films::", err.Error())
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func withoutFilm(filmIDs []primitive.ObjectID, filmID primitive.ObjectID) []primitive.ObjectID {
+	result := []primitive.ObjectID{}
+	for _, linked := range filmIDs {
+		if linked != filmID {
+			result = append(result, linked)
+		}
+	}
+	return result
+}
+
 func (pd *planetsDAO) find(ctx context.Context, filter interface{}) ([]models.Planet, error) {
 	var planets []models.Planet
 	cursor, err := pd.db.Collection(COLLECTION).Find(ctx, filter)
 	if err != nil {
-		log.WithField("filter", filter).Error("There was an error finding the planets::", err.Error())
+		logging.FromContext(ctx).WithField("filter", filter).Error("There was an error finding the planets::", err.Error())
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 	if err := cursor.All(ctx, &planets); err != nil {
-		log.Error(err)
+		logging.FromContext(ctx).Error(err)
 		return nil, err
 	}
 	return planets, nil
@@ -111,7 +259,7 @@ func (pd *planetsDAO) findOne(ctx context.Context, filter interface{}) (*models.
 	var planet models.Planet
 	if err := pd.db.Collection(COLLECTION).FindOne(ctx, filter).Decode(&planet); err != nil {
 		if err == mongo.ErrNoDocuments {
-			log.Error(err)
+			logging.FromContext(ctx).Error(err)
 			return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
 		}
 		return nil, err
@@ -119,10 +267,10 @@ func (pd *planetsDAO) findOne(ctx context.Context, filter interface{}) (*models.
 	return &planet, nil
 }
 
-func createObjectIDFromHex(id string) (*primitive.ObjectID, error) {
+func createObjectIDFromHex(ctx context.Context, id string) (*primitive.ObjectID, error) {
 	idPrimitive, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		log.Error(err)
+		logging.FromContext(ctx).Error(err)
 		return nil, errors.New(INVALID_ID_ERROR_MESSAGE)
 	}
 
@@ -0,0 +1,272 @@
+package dao
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// planetsBackends are the databases the planets DAO suite runs against: the
+// mongo DAO through the in-memory driver and the bolt DAO on a temporary file
+var planetsBackends = map[string]func(t *testing.T) db.DatabaseHelper{
+	"mongo": func(t *testing.T) db.DatabaseHelper {
+		return db.NewMemoryClient().Database("test")
+	},
+	"bolt": func(t *testing.T) db.DatabaseHelper {
+		client, err := db.NewBoltClient(filepath.Join(t.TempDir(), "planets.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.Database("test")
+	},
+}
+
+func runPlanetsSuite(t *testing.T, test func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper)) {
+	for name, backend := range planetsBackends {
+		t.Run(name, func(t *testing.T) {
+			database := backend(t)
+			test(t, NewPlanetsDao(database), database)
+		})
+	}
+}
+
+func createPlanets(t *testing.T, planets PlanetsDAO, names ...string) []models.Planet {
+	created := []models.Planet{}
+	for _, name := range names {
+		planet := models.Planet{ID: primitive.NewObjectID(), Name: name, Climate: models.StringList{"arid"}}
+		assert.NoError(t, planets.Create(context.Background(), &planet))
+		created = append(created, planet)
+	}
+	return created
+}
+
+func planetNames(planets []models.Planet) []string {
+	names := []string{}
+	for _, planet := range planets {
+		names = append(names, planet.Name)
+	}
+	return names
+}
+
+func TestPlanetsSuite_Create_and_find(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		filmID := primitive.NewObjectID()
+		planet := models.Planet{
+			ID:      primitive.NewObjectID(),
+			Name:    "Tatooine",
+			Climate: models.StringList{"arid"},
+			Terrain: models.StringList{"desert"},
+			Films:   9,
+			FilmIDs: []primitive.ObjectID{filmID},
+		}
+		assert.NoError(t, planets.Create(context.Background(), &planet))
+		assert.Equal(t, 1, planet.Films)
+
+		found, err := planets.FindByID(context.Background(), planet.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, planet, *found)
+
+		all, err := planets.FindAll(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Planet{planet}, all)
+	})
+}
+
+func TestPlanetsSuite_FindByID_errors(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		_, err := planets.FindByID(context.Background(), "12345")
+		assert.EqualError(t, err, INVALID_ID_ERROR_MESSAGE)
+
+		_, err = planets.FindByID(context.Background(), primitive.NewObjectID().Hex())
+		assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+	})
+}
+
+func TestPlanetsSuite_FindByName(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		createPlanets(t, planets, "Tatooine", "Hoth", "Naboo")
+
+		found, err := planets.FindByName(context.Background(), "OO")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Tatooine", "Naboo"}, planetNames(found))
+
+		found, err = planets.FindByName(context.Background(), "^hoth$")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Hoth"}, planetNames(found))
+
+		found, err = planets.FindByName(context.Background(), "Dagobah")
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+	})
+}
+
+func TestPlanetsSuite_Delete(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		created := createPlanets(t, planets, "Hoth")
+
+		assert.NoError(t, planets.Delete(context.Background(), created[0].ID.Hex()))
+		assert.EqualError(t, planets.Delete(context.Background(), created[0].ID.Hex()), NOT_FOUND_ERROR_MESSAGE)
+
+		found, err := planets.FindByName(context.Background(), "hoth")
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+	})
+}
+
+func TestPlanetsSuite_UpsertByName(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		created := createPlanets(t, planets, "Hoth", "hoth")
+
+		planet := models.Planet{Name: "Hoth", Climate: models.StringList{"frozen"}, FilmIDs: []primitive.ObjectID{primitive.NewObjectID()}}
+		assert.NoError(t, planets.UpsertByName(context.Background(), &planet))
+		assert.Equal(t, created[0].ID, planet.ID)
+
+		found, err := planets.FindByID(context.Background(), created[0].ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, models.StringList{"frozen"}, found.Climate)
+		assert.Equal(t, 1, found.Films)
+
+		planet = models.Planet{Name: "Dagobah"}
+		assert.NoError(t, planets.UpsertByName(context.Background(), &planet))
+		assert.False(t, planet.ID.IsZero())
+
+		all, err := planets.FindAll(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, all, 3)
+	})
+}
+
+func TestPlanetsSuite_films(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		created := createPlanets(t, planets, "Tatooine", "Hoth")
+		filmID := primitive.NewObjectID().Hex()
+
+		assert.NoError(t, planets.AddFilm(context.Background(), created[0].ID.Hex(), filmID))
+		assert.NoError(t, planets.AddFilm(context.Background(), created[0].ID.Hex(), filmID))
+		assert.NoError(t, planets.AddFilm(context.Background(), created[1].ID.Hex(), filmID))
+		assert.EqualError(t, planets.AddFilm(context.Background(), primitive.NewObjectID().Hex(), filmID), NOT_FOUND_ERROR_MESSAGE)
+		assert.EqualError(t, planets.AddFilm(context.Background(), created[0].ID.Hex(), "12345"), INVALID_FILM_ID_ERROR_MESSAGE)
+
+		found, err := planets.FindByFilm(context.Background(), filmID)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Tatooine", "Hoth"}, planetNames(found))
+		for _, planet := range found {
+			assert.Equal(t, 1, planet.Films)
+		}
+
+		assert.NoError(t, planets.RemoveFilm(context.Background(), created[1].ID.Hex(), filmID))
+		assert.EqualError(t, planets.RemoveFilm(context.Background(), created[1].ID.Hex(), filmID), NOT_FOUND_ERROR_MESSAGE)
+
+		assert.NoError(t, planets.UnlinkFilm(context.Background(), filmID))
+		found, err = planets.FindByFilm(context.Background(), filmID)
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+
+		planet, err := planets.FindByID(context.Background(), created[0].ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, planet.Films)
+	})
+}
+
+func TestPlanetsSuite_concurrent_films(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		created := createPlanets(t, planets, "Tatooine")
+		id := created[0].ID.Hex()
+		filmIDs := []string{}
+		for i := 0; i < 8; i++ {
+			filmIDs = append(filmIDs, primitive.NewObjectID().Hex())
+		}
+
+		var wg sync.WaitGroup
+		for _, filmID := range filmIDs {
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func(filmID string) {
+					defer wg.Done()
+					assert.NoError(t, planets.AddFilm(context.Background(), id, filmID))
+				}(filmID)
+			}
+		}
+		wg.Wait()
+		for _, filmID := range filmIDs[:4] {
+			wg.Add(1)
+			go func(filmID string) {
+				defer wg.Done()
+				assert.NoError(t, planets.RemoveFilm(context.Background(), id, filmID))
+			}(filmID)
+		}
+		wg.Wait()
+
+		planet, err := planets.FindByID(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Len(t, planet.FilmIDs, 4)
+		assert.Equal(t, 4, planet.Films)
+	})
+}
+
+func TestPlanetsSuite_Stats(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		for _, planet := range []models.Planet{
+			{Name: "Tatooine", Climate: models.StringList{"arid"}, Terrain: models.StringList{"desert"}, FilmIDs: []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}},
+			{Name: "Geonosis", Climate: models.StringList{"arid", "temperate"}, Terrain: models.StringList{"rock"}},
+			{Name: "Naboo", Climate: models.StringList{"temperate"}, Terrain: models.StringList{"swamp"}, FilmIDs: []primitive.ObjectID{primitive.NewObjectID()}},
+			{Name: "Hoth", Climate: models.StringList{"frozen"}, Terrain: models.StringList{"tundra"}, FilmIDs: []primitive.ObjectID{primitive.NewObjectID()}},
+		} {
+			planet := planet
+			planet.ID = primitive.NewObjectID()
+			assert.NoError(t, planets.Create(context.Background(), &planet))
+		}
+
+		stats, err := planets.Stats(context.Background(), "", models.StatsFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, GROUP_BY_CLIMATE, stats.GroupBy)
+		assert.Equal(t, 4, stats.Total)
+		assert.Equal(t, models.FilmsSummary{Avg: 1, Min: 0, Max: 2}, stats.Films)
+		assert.Len(t, stats.Groups, 3)
+		assert.Equal(t, "arid", stats.Groups[0].Value)
+		assert.Equal(t, 2, stats.Groups[0].Count)
+		assert.Equal(t, "temperate", stats.Groups[1].Value)
+		assert.Equal(t, "frozen", stats.Groups[2].Value)
+
+		minFilms := 1
+		stats, err = planets.Stats(context.Background(), GROUP_BY_FILMS, models.StatsFilter{Climate: "Temperate", MinFilms: &minFilms})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, stats.Total)
+		assert.Len(t, stats.Groups, 1)
+		assert.EqualValues(t, 1, stats.Groups[0].Value)
+
+		_, err = planets.Stats(context.Background(), "size", models.StatsFilter{})
+		assert.EqualError(t, err, INVALID_GROUP_BY_ERROR_MESSAGE)
+	})
+}
+
+func TestPlanetsSuite_residents(t *testing.T) {
+	runPlanetsSuite(t, func(t *testing.T, planets PlanetsDAO, database db.DatabaseHelper) {
+		created := createPlanets(t, planets, "Tatooine", "Hoth")
+		people := NewPeopleDao(database)
+		luke := models.Person{ID: primitive.NewObjectID(), Name: "Luke", Homeworld: &created[0].ID}
+		assert.NoError(t, people.Create(context.Background(), &luke))
+
+		expanded, err := planets.FindByIDWithResidents(context.Background(), created[0].ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, []models.ResidentSummary{{ID: luke.ID, Name: "Luke"}}, expanded.Residents)
+
+		all, err := planets.FindAllWithResidents(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		byName, err := planets.FindByNameWithResidents(context.Background(), "hoth")
+		assert.NoError(t, err)
+		assert.Len(t, byName, 1)
+		assert.Empty(t, byName[0].Residents)
+
+		_, err = planets.FindByIDWithResidents(context.Background(), primitive.NewObjectID().Hex())
+		assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/wallacebenevides/star-wars-api/mocks"
 	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -22,7 +23,7 @@ func Test_planetsDAO_FindAll(t *testing.T) {
 	id, _ := primitive.ObjectIDFromHex("12345")
 
 	expected := []models.Planet{
-		{ID: id, Name: "mocked-planet", Climate: ""},
+		{ID: id, Name: "mocked-planet"},
 	}
 
 	dbHelper.
@@ -304,7 +305,7 @@ func Test_planetsDAO_FindByName(t *testing.T) {
 	id, _ := primitive.ObjectIDFromHex("12345")
 
 	expected := []models.Planet{
-		{ID: id, Name: "mocked-planet", Climate: ""},
+		{ID: id, Name: "mocked-planet"},
 	}
 
 	dbHelper.
@@ -332,3 +333,196 @@ func Test_planetsDAO_FindByName(t *testing.T) {
 	assert.Equal(t, expected, planets)
 	assert.NoError(t, err)
 }
+
+func Test_planetsDAO_AddFilm(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	planetID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+	filmID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cca")
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), bson.M{"_id": &planetID, "film_ids": bson.M{"$ne": &filmID}},
+			bson.M{"$addToSet": bson.M{"film_ids": &filmID}, "$inc": bson.M{"films": 1}}).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	planetDao := NewPlanetsDao(dbHelper)
+
+	err := planetDao.AddFilm(context.Background(), planetID.Hex(), filmID.Hex())
+	assert.NoError(t, err)
+	collectionHelper.AssertExpectations(t)
+}
+
+func Test_planetsDAO_AddFilm_with_linked_film(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	srHelper := &mocks.SingleResultHelper{}
+
+	planetID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), mock.Anything, mock.Anything).
+		Once().
+		Return(&mongo.UpdateResult{}, nil)
+
+	srHelper.
+		On("Decode", mock.AnythingOfType("*models.Planet")).
+		Once().
+		Return(nil)
+
+	collectionHelper.
+		On("FindOne", context.Background(), bson.M{"_id": &planetID}).
+		Once().
+		Return(srHelper)
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	planetDao := NewPlanetsDao(dbHelper)
+
+	err := planetDao.AddFilm(context.Background(), planetID.Hex(), "5e27096d0c326694932a4cca")
+	assert.NoError(t, err)
+	collectionHelper.AssertExpectations(t)
+}
+
+func Test_planetsDAO_AddFilm_with_invalid_film_id_error(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+
+	planetDao := NewPlanetsDao(dbHelper)
+
+	err := planetDao.AddFilm(context.Background(), "5e27096d0c326694932a4cc8", "invalid id")
+	assert.EqualError(t, err, INVALID_FILM_ID_ERROR_MESSAGE)
+}
+
+func Test_planetsDAO_RemoveFilm_with_not_linked_film(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	planetID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+	filmID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cca")
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), bson.M{"_id": &planetID, "film_ids": &filmID},
+			bson.M{"$pull": bson.M{"film_ids": &filmID}, "$inc": bson.M{"films": -1}}).
+		Once().
+		Return(&mongo.UpdateResult{}, nil)
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	planetDao := NewPlanetsDao(dbHelper)
+
+	err := planetDao.RemoveFilm(context.Background(), planetID.Hex(), filmID.Hex())
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+	collectionHelper.AssertExpectations(t)
+}
+
+func Test_planetsDAO_UnlinkFilm(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	filmID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cca")
+	filter := bson.M{"film_ids": &filmID}
+	update := bson.M{"$pull": bson.M{"film_ids": &filmID}, "$inc": bson.M{"films": -1}}
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), filter, update).
+		Twice().
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), filter, update).
+		Once().
+		Return(&mongo.UpdateResult{}, nil)
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	planetDao := NewPlanetsDao(dbHelper)
+
+	err := planetDao.UnlinkFilm(context.Background(), filmID.Hex())
+	assert.NoError(t, err)
+	collectionHelper.AssertExpectations(t)
+}
+
+func Test_planetsDAO_UpsertByName_with_new_planet(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	srHelper := &mocks.SingleResultHelper{}
+
+	srHelper.
+		On("Decode", mock.AnythingOfType("*models.Planet")).
+		Once().
+		Return(mongo.ErrNoDocuments)
+
+	collectionHelper.
+		On("FindOne", context.Background(), bson.M{"name": "Tatooine"}).
+		Once().
+		Return(srHelper)
+	collectionHelper.
+		On("InsertOne", context.Background(), mock.AnythingOfType("*models.Planet")).
+		Once().
+		Return(nil, nil)
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	planet := &models.Planet{Name: "Tatooine", FilmIDs: []primitive.ObjectID{primitive.NewObjectID()}}
+	err := NewPlanetsDao(dbHelper).UpsertByName(context.Background(), planet)
+
+	assert.NoError(t, err)
+	assert.False(t, planet.ID.IsZero())
+	assert.Equal(t, 1, planet.Films)
+	collectionHelper.AssertExpectations(t)
+}
+
+func Test_planetsDAO_UpsertByName_with_existing_planet(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	srHelper := &mocks.SingleResultHelper{}
+
+	id, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+	srHelper.
+		On("Decode", mock.AnythingOfType("*models.Planet")).
+		Once().
+		Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*models.Planet)
+		arg.ID = id
+	})
+
+	collectionHelper.
+		On("FindOne", context.Background(), bson.M{"name": "Tatooine"}).
+		Once().
+		Return(srHelper)
+	collectionHelper.
+		On("UpdateOne", context.Background(), bson.M{"_id": id}, mock.Anything).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	planet := &models.Planet{Name: "Tatooine", Climate: models.StringList{"arid"}}
+	err := NewPlanetsDao(dbHelper).UpsertByName(context.Background(), planet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, id, planet.ID)
+	collectionHelper.AssertExpectations(t)
+}
@@ -0,0 +1,142 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/metrics"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"github.com/wallacebenevides/star-wars-api/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	OUTCOME_OK    = "ok"
+	OUTCOME_ERROR = "error"
+)
+
+// instrumentedPlanetsDAO traces every call to the wrapped DAO and observes its duration
+type instrumentedPlanetsDAO struct {
+	next PlanetsDAO
+}
+
+func NewInstrumentedPlanetsDao(next PlanetsDAO) PlanetsDAO {
+	return &instrumentedPlanetsDAO{next: next}
+}
+
+// start opens the span of an operation, the returned function ends it and records its duration
+func start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	begin := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "PlanetsDAO."+operation,
+		trace.WithAttributes(attribute.String("db.collection", COLLECTION)))
+	return ctx, func(err error) {
+		outcome := OUTCOME_OK
+		if err != nil {
+			outcome = OUTCOME_ERROR
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		metrics.DAODuration.WithLabelValues(COLLECTION, operation, outcome).Observe(time.Since(begin).Seconds())
+	}
+}
+
+func (pd *instrumentedPlanetsDAO) FindAll(ctx context.Context) ([]models.Planet, error) {
+	ctx, finish := start(ctx, "FindAll")
+	result, err := pd.next.FindAll(ctx)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) Create(ctx context.Context, planet *models.Planet) error {
+	ctx, finish := start(ctx, "Create")
+	err := pd.next.Create(ctx, planet)
+	finish(err)
+	return err
+}
+
+func (pd *instrumentedPlanetsDAO) FindByID(ctx context.Context, id string) (*models.Planet, error) {
+	ctx, finish := start(ctx, "FindByID")
+	result, err := pd.next.FindByID(ctx, id)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) FindByName(ctx context.Context, name string) ([]models.Planet, error) {
+	ctx, finish := start(ctx, "FindByName")
+	result, err := pd.next.FindByName(ctx, name)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) Delete(ctx context.Context, id string) error {
+	ctx, finish := start(ctx, "Delete")
+	err := pd.next.Delete(ctx, id)
+	finish(err)
+	return err
+}
+
+func (pd *instrumentedPlanetsDAO) Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (*models.PlanetStats, error) {
+	ctx, finish := start(ctx, "Stats")
+	result, err := pd.next.Stats(ctx, groupBy, filter)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) FindByFilm(ctx context.Context, filmID string) ([]models.Planet, error) {
+	ctx, finish := start(ctx, "FindByFilm")
+	result, err := pd.next.FindByFilm(ctx, filmID)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) AddFilm(ctx context.Context, id string, filmID string) error {
+	ctx, finish := start(ctx, "AddFilm")
+	err := pd.next.AddFilm(ctx, id, filmID)
+	finish(err)
+	return err
+}
+
+func (pd *instrumentedPlanetsDAO) RemoveFilm(ctx context.Context, id string, filmID string) error {
+	ctx, finish := start(ctx, "RemoveFilm")
+	err := pd.next.RemoveFilm(ctx, id, filmID)
+	finish(err)
+	return err
+}
+
+func (pd *instrumentedPlanetsDAO) UnlinkFilm(ctx context.Context, filmID string) error {
+	ctx, finish := start(ctx, "UnlinkFilm")
+	err := pd.next.UnlinkFilm(ctx, filmID)
+	finish(err)
+	return err
+}
+
+func (pd *instrumentedPlanetsDAO) FindAllWithResidents(ctx context.Context) ([]models.ExpandedPlanet, error) {
+	ctx, finish := start(ctx, "FindAllWithResidents")
+	result, err := pd.next.FindAllWithResidents(ctx)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) FindByIDWithResidents(ctx context.Context, id string) (*models.ExpandedPlanet, error) {
+	ctx, finish := start(ctx, "FindByIDWithResidents")
+	result, err := pd.next.FindByIDWithResidents(ctx, id)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) FindByNameWithResidents(ctx context.Context, name string) ([]models.ExpandedPlanet, error) {
+	ctx, finish := start(ctx, "FindByNameWithResidents")
+	result, err := pd.next.FindByNameWithResidents(ctx, name)
+	finish(err)
+	return result, err
+}
+
+func (pd *instrumentedPlanetsDAO) UpsertByName(ctx context.Context, planet *models.Planet) error {
+	ctx, finish := start(ctx, "UpsertByName")
+	err := pd.next.UpsertByName(ctx, planet)
+	finish(err)
+	return err
+}
@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func daoSampleCount(operation string, outcome string) uint64 {
+	var histogram dto.Metric
+	metrics.DAODuration.WithLabelValues(COLLECTION, operation, outcome).(prometheus.Metric).Write(&histogram)
+	return histogram.GetHistogram().GetSampleCount()
+}
+
+func Test_instrumentedPlanetsDAO(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	next := &mocks.PlanetsDAO{}
+	next.On("FindAll", mock.Anything).Once().Return([]models.Planet{{Name: "Hoth"}}, nil)
+	next.On("Delete", mock.Anything, "12345").Once().Return(errors.New(INVALID_ID_ERROR_MESSAGE))
+	findAll, deleteErrors := daoSampleCount("FindAll", OUTCOME_OK), daoSampleCount("Delete", OUTCOME_ERROR)
+
+	dao := NewInstrumentedPlanetsDao(next)
+	planets, err := dao.FindAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Planet{{Name: "Hoth"}}, planets)
+	assert.EqualError(t, dao.Delete(context.Background(), "12345"), INVALID_ID_ERROR_MESSAGE)
+
+	assert.Equal(t, findAll+1, daoSampleCount("FindAll", OUTCOME_OK))
+	assert.Equal(t, deleteErrors+1, daoSampleCount("Delete", OUTCOME_ERROR))
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "PlanetsDAO.FindAll", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "PlanetsDAO.Delete", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	next.AssertExpectations(t)
+}
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (pd *planetsDAO) FindAllWithResidents(ctx context.Context) ([]models.ExpandedPlanet, error) {
+	return pd.findWithResidents(ctx, bson.M{})
+}
+
+func (pd *planetsDAO) FindByIDWithResidents(ctx context.Context, id string) (*models.ExpandedPlanet, error) {
+	objectID, err := createObjectIDFromHex(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	planets, err := pd.findWithResidents(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+	if len(planets) == 0 {
+		return nil, errors.New(NOT_FOUND_ERROR_MESSAGE)
+	}
+	return &planets[0], nil
+}
+
+func (pd *planetsDAO) FindByNameWithResidents(ctx context.Context, name string) ([]models.ExpandedPlanet, error) {
+	return pd.findWithResidents(ctx, bson.M{"name": primitive.Regex{Pattern: name, Options: "i"}})
+}
+
+// findWithResidents looks up the residents of the matched planets in the same query
+func (pd *planetsDAO) findWithResidents(ctx context.Context, match bson.M) ([]models.ExpandedPlanet, error) {
+	planets := []models.ExpandedPlanet{}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: PEOPLE_COLLECTION},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "homeworld"},
+			{Key: "as", Value: "residents"},
+		}}},
+		{{Key: "$addFields", Value: bson.D{
+			{Key: "residents", Value: bson.D{{Key: "$map", Value: bson.D{
+				{Key: "input", Value: "$residents"},
+				{Key: "as", Value: "r"},
+				{Key: "in", Value: bson.D{{Key: "_id", Value: "$$r._id"}, {Key: "name", Value: "$$r.name"}}},
+			}}}},
+		}}},
+	}
+	if err := pd.aggregate(ctx, pipeline, &planets); err != nil {
+		return nil, err
+	}
+	return planets, nil
+}
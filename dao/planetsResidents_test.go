@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+)
+
+func Test_planetsDAO_FindByIDWithResidents(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	expected := models.ExpandedPlanet{
+		Planet:    models.Planet{Name: "Tatooine"},
+		Residents: []models.ResidentSummary{{Name: "Luke Skywalker"}},
+	}
+
+	dbHelper.
+		On("Collection", "planets").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Aggregate", context.Background(), mock.Anything).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.ExpandedPlanet)
+			*arg = []models.ExpandedPlanet{expected}
+		}).
+		Return(nil)
+
+	dao := NewPlanetsDao(dbHelper)
+	planet, err := dao.FindByIDWithResidents(context.Background(), "5e27096d0c326694932a4cc8")
+
+	assert.Equal(t, &expected, planet)
+	assert.NoError(t, err)
+}
+
+func Test_planetsDAO_FindByIDWithResidents_with_not_found(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	dbHelper.
+		On("Collection", "planets").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Aggregate", context.Background(), mock.Anything).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).Return(nil)
+
+	dao := NewPlanetsDao(dbHelper)
+	planet, err := dao.FindByIDWithResidents(context.Background(), "5e27096d0c326694932a4cc8")
+
+	assert.Nil(t, planet)
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+}
+
+func Test_planetsDAO_FindByIDWithResidents_with_invalid_id_error(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+
+	dao := NewPlanetsDao(dbHelper)
+	planet, err := dao.FindByIDWithResidents(context.Background(), "invalid id")
+
+	assert.Nil(t, planet)
+	assert.EqualError(t, err, INVALID_ID_ERROR_MESSAGE)
+}
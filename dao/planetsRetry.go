@@ -0,0 +1,274 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"github.com/wallacebenevides/star-wars-api/retry"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	NETWORK_ERROR_LABEL         = "NetworkError"
+	RETRYABLE_WRITE_ERROR_LABEL = "RetryableWriteError"
+	// ID_INDEX is named in the duplicate key errors on _id
+	ID_INDEX = "_id_"
+)
+
+// RETRYABLE_CODES are the server errors of a step-down, a shutdown or the network
+var RETRYABLE_CODES = map[int32]bool{
+	6:     true, // HostUnreachable
+	7:     true, // HostNotFound
+	89:    true, // NetworkTimeout
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	262:   true, // ExceededTimeLimit
+	9001:  true, // SocketException
+	10107: true, // NotMaster
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotMasterNoSlaveOk
+	13436: true, // NotMasterOrSecondary
+}
+
+// retryPlanetsDAO repeats the idempotent calls and the safe writes failing
+// with a transient mongo error. The writes that aren't safe to repeat, like
+// UpsertByName, are called once.
+type retryPlanetsDAO struct {
+	next PlanetsDAO
+	cnf  config.Retry
+}
+
+func NewRetryPlanetsDao(next PlanetsDAO, cnf config.Retry) PlanetsDAO {
+	return &retryPlanetsDAO{next: next, cnf: cnf}
+}
+
+// IsRetryable tells the transient mongo errors, those of the network, of the
+// server selection and of a primary stepping down
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) {
+		return RETRYABLE_CODES[commandErr.Code] ||
+			commandErr.HasErrorLabel(NETWORK_ERROR_LABEL) ||
+			commandErr.HasErrorLabel(RETRYABLE_WRITE_ERROR_LABEL)
+	}
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		return writeErr.WriteConcernError != nil && RETRYABLE_CODES[int32(writeErr.WriteConcernError.Code)]
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "server selection error") || strings.HasPrefix(message, "connection(")
+}
+
+// MayHaveApplied tells the transient errors of a write that may have reached
+// the server and been applied: the network errors once the command was sent
+// and the write concern errors. The server selection, dial and handshake
+// errors and the commands refused by the server, e.g. on a step down, prove
+// the write wasn't applied.
+func MayHaveApplied(err error) bool {
+	if err == nil {
+		return false
+	}
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.HasErrorLabel(NETWORK_ERROR_LABEL)
+	}
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		return writeErr.WriteConcernError != nil
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op != "dial"
+	}
+	message := err.Error()
+	return strings.HasPrefix(message, "connection(") && !strings.Contains(message, "handshake")
+}
+
+// do calls op until it succeeds, fails with an error that isn't retryable,
+// runs out of attempts or the request deadline comes before the next attempt
+func (pd *retryPlanetsDAO) do(ctx context.Context, operation string, op func() error) error {
+	logger := logging.FromContext(ctx).WithField("operation", "PlanetsDAO."+operation)
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil && attempt > 1 {
+			logger.WithField("retries", attempt-1).Info("Succeeded after retrying")
+		}
+		if err == nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt >= pd.cnf.Attempts {
+			if attempt > 1 {
+				logger.WithError(err).WithField("retries", attempt-1).Warn("Giving up, out of attempts")
+			}
+			return err
+		}
+		delay := retry.Delay(pd.cnf.Backoff, attempt-1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			logger.WithError(err).WithField("retries", attempt-1).Warn("Giving up, the request deadline is too close")
+			return err
+		}
+		logger.WithError(err).WithField("retry", attempt).Warn("Retrying in ", delay)
+		if retry.Sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+func (pd *retryPlanetsDAO) FindAll(ctx context.Context) (result []models.Planet, err error) {
+	err = pd.do(ctx, "FindAll", func() error {
+		result, err = pd.next.FindAll(ctx)
+		return err
+	})
+	return result, err
+}
+
+// Create gives the planet its id before the first attempt, so a duplicate
+// _id on a retry means an earlier attempt was written
+func (pd *retryPlanetsDAO) Create(ctx context.Context, planet *models.Planet) error {
+	if planet.ID.IsZero() {
+		planet.ID = db.ObjectID().NewObjectID()
+	}
+	retried := false
+	return pd.do(ctx, "Create", func() error {
+		err := pd.next.Create(ctx, planet)
+		if retried && isDuplicateID(err) {
+			return nil
+		}
+		retried = true
+		return err
+	})
+}
+
+func (pd *retryPlanetsDAO) FindByID(ctx context.Context, id string) (result *models.Planet, err error) {
+	err = pd.do(ctx, "FindByID", func() error {
+		result, err = pd.next.FindByID(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (pd *retryPlanetsDAO) FindByName(ctx context.Context, name string) (result []models.Planet, err error) {
+	err = pd.do(ctx, "FindByName", func() error {
+		result, err = pd.next.FindByName(ctx, name)
+		return err
+	})
+	return result, err
+}
+
+// Delete takes a missing planet on a retry for the delete of an earlier
+// attempt, when that attempt may have been applied
+func (pd *retryPlanetsDAO) Delete(ctx context.Context, id string) error {
+	return pd.doWrite(ctx, "Delete", func() error {
+		return pd.next.Delete(ctx, id)
+	})
+}
+
+// doWrite retries a write whose repeat fails with NOT_FOUND_ERROR_MESSAGE
+// once it was applied, e.g. a delete: the not found error of a retry is a
+// success when an earlier attempt may have been applied, and a genuine one
+// when they all failed before reaching the server
+func (pd *retryPlanetsDAO) doWrite(ctx context.Context, operation string, op func() error) error {
+	mayHaveApplied := false
+	return pd.do(ctx, operation, func() error {
+		err := op()
+		if mayHaveApplied && err != nil && err.Error() == NOT_FOUND_ERROR_MESSAGE {
+			return nil
+		}
+		mayHaveApplied = mayHaveApplied || MayHaveApplied(err)
+		return err
+	})
+}
+
+func (pd *retryPlanetsDAO) Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (result *models.PlanetStats, err error) {
+	err = pd.do(ctx, "Stats", func() error {
+		result, err = pd.next.Stats(ctx, groupBy, filter)
+		return err
+	})
+	return result, err
+}
+
+func (pd *retryPlanetsDAO) FindByFilm(ctx context.Context, filmID string) (result []models.Planet, err error) {
+	err = pd.do(ctx, "FindByFilm", func() error {
+		result, err = pd.next.FindByFilm(ctx, filmID)
+		return err
+	})
+	return result, err
+}
+
+// AddFilm and UnlinkFilm only update the planets not linked, or still
+// linked, to the film, repeating them doesn't change the result
+func (pd *retryPlanetsDAO) AddFilm(ctx context.Context, id string, filmID string) error {
+	return pd.do(ctx, "AddFilm", func() error {
+		return pd.next.AddFilm(ctx, id, filmID)
+	})
+}
+
+// RemoveFilm fails with NOT_FOUND_ERROR_MESSAGE once the link is gone, it is
+// retried like Delete
+func (pd *retryPlanetsDAO) RemoveFilm(ctx context.Context, id string, filmID string) error {
+	return pd.doWrite(ctx, "RemoveFilm", func() error {
+		return pd.next.RemoveFilm(ctx, id, filmID)
+	})
+}
+
+func (pd *retryPlanetsDAO) UnlinkFilm(ctx context.Context, filmID string) error {
+	return pd.do(ctx, "UnlinkFilm", func() error {
+		return pd.next.UnlinkFilm(ctx, filmID)
+	})
+}
+
+func (pd *retryPlanetsDAO) FindAllWithResidents(ctx context.Context) (result []models.ExpandedPlanet, err error) {
+	err = pd.do(ctx, "FindAllWithResidents", func() error {
+		result, err = pd.next.FindAllWithResidents(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (pd *retryPlanetsDAO) FindByIDWithResidents(ctx context.Context, id string) (result *models.ExpandedPlanet, err error) {
+	err = pd.do(ctx, "FindByIDWithResidents", func() error {
+		result, err = pd.next.FindByIDWithResidents(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (pd *retryPlanetsDAO) FindByNameWithResidents(ctx context.Context, name string) (result []models.ExpandedPlanet, err error) {
+	err = pd.do(ctx, "FindByNameWithResidents", func() error {
+		result, err = pd.next.FindByNameWithResidents(ctx, name)
+		return err
+	})
+	return result, err
+}
+
+func (pd *retryPlanetsDAO) UpsertByName(ctx context.Context, planet *models.Planet) error {
+	return pd.next.UpsertByName(ctx, planet)
+}
+
+func isDuplicateID(err error) bool {
+	var writeErr mongo.WriteException
+	if !errors.As(err, &writeErr) {
+		return false
+	}
+	for _, e := range writeErr.WriteErrors {
+		if e.Code == DUPLICATE_KEY_CODE && strings.Contains(e.Message, ID_INDEX) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	testRetry     = config.Retry{Attempts: 3, Backoff: config.Backoff{Initial: time.Millisecond, Max: time.Millisecond}}
+	steppedDown   = mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+	networkError  = mongo.CommandError{Labels: []string{NETWORK_ERROR_LABEL}}
+	duplicateName = mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: DUPLICATE_KEY_CODE, Message: "E11000 duplicate key error index: name_1"}}}
+	duplicateID   = mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: DUPLICATE_KEY_CODE, Message: "E11000 duplicate key error index: _id_"}}}
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"not found", errors.New(NOT_FOUND_ERROR_MESSAGE), false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"step down", steppedDown, true},
+		{"network label", mongo.CommandError{Labels: []string{NETWORK_ERROR_LABEL}}, true},
+		{"duplicate key", duplicateName, false},
+		{"write concern", mongo.WriteException{WriteConcernError: &mongo.WriteConcernError{Code: 91}}, true},
+		{"server selection", errors.New("server selection error: server selection timeout"), true},
+		{"connection", errors.New("connection(localhost:27017[-3]) incomplete read of message header: EOF"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func Test_retryPlanetsDAO_FindAll(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("FindAll", mock.Anything).Twice().Return(nil, steppedDown)
+	next.On("FindAll", mock.Anything).Once().Return([]models.Planet{{Name: "Hoth"}}, nil)
+
+	planets, err := NewRetryPlanetsDao(next, testRetry).FindAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Planet{{Name: "Hoth"}}, planets)
+	next.AssertExpectations(t)
+}
+
+func Test_retryPlanetsDAO_gives_up(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("FindByID", mock.Anything, "12345").Times(3).Return(nil, steppedDown)
+	next.On("FindByName", mock.Anything, "Hoth").Once().Return(nil, errors.New(NOT_FOUND_ERROR_MESSAGE))
+	dao := NewRetryPlanetsDao(next, testRetry)
+
+	_, err := dao.FindByID(context.Background(), "12345")
+	assert.Equal(t, steppedDown, err)
+
+	_, err = dao.FindByName(context.Background(), "Hoth")
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+	next.AssertExpectations(t)
+}
+
+func Test_retryPlanetsDAO_within_deadline(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("FindAll", mock.Anything).Once().Return(nil, steppedDown)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := NewRetryPlanetsDao(next, config.Retry{Attempts: 3, Backoff: config.Backoff{Initial: time.Second, Max: time.Second}}).FindAll(ctx)
+
+	assert.Equal(t, steppedDown, err)
+	next.AssertExpectations(t)
+}
+
+func Test_retryPlanetsDAO_Create(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("Create", mock.Anything, mock.Anything).Once().Return(steppedDown)
+	next.On("Create", mock.Anything, mock.Anything).Once().Return(duplicateID)
+	planet := &models.Planet{Name: "Hoth"}
+
+	err := NewRetryPlanetsDao(next, testRetry).Create(context.Background(), planet)
+
+	assert.NoError(t, err)
+	assert.False(t, planet.ID.IsZero())
+	next.AssertExpectations(t)
+
+	next = &mocks.PlanetsDAO{}
+	next.On("Create", mock.Anything, mock.Anything).Once().Return(duplicateName)
+	err = NewRetryPlanetsDao(next, testRetry).Create(context.Background(), &models.Planet{Name: "Hoth"})
+	assert.Equal(t, duplicateName, err)
+}
+
+func TestMayHaveApplied(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"step down", steppedDown, false},
+		{"network label", networkError, true},
+		{"write concern", mongo.WriteException{WriteConcernError: &mongo.WriteConcernError{Code: 91}}, true},
+		{"server selection", errors.New("server selection error: server selection timeout"), false},
+		{"dial", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, false},
+		{"read", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true},
+		{"connection", errors.New("connection(localhost:27017[-3]) incomplete read of message header: EOF"), true},
+		{"handshake", errors.New("connection() error occurred during connection handshake: EOF"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MayHaveApplied(tt.err))
+		})
+	}
+}
+
+func Test_retryPlanetsDAO_Delete(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("Delete", mock.Anything, "12345").Once().Return(networkError)
+	next.On("Delete", mock.Anything, "12345").Once().Return(errors.New(NOT_FOUND_ERROR_MESSAGE))
+
+	err := NewRetryPlanetsDao(next, testRetry).Delete(context.Background(), "12345")
+
+	assert.NoError(t, err, "the first attempt may have deleted the planet")
+	next.AssertExpectations(t)
+
+	next = &mocks.PlanetsDAO{}
+	next.On("Delete", mock.Anything, "12345").Once().Return(steppedDown)
+	next.On("Delete", mock.Anything, "12345").Once().Return(errors.New(NOT_FOUND_ERROR_MESSAGE))
+
+	err = NewRetryPlanetsDao(next, testRetry).Delete(context.Background(), "12345")
+
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE, "the first attempt was refused")
+	next.AssertExpectations(t)
+}
+
+func Test_retryPlanetsDAO_RemoveFilm(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("RemoveFilm", mock.Anything, "12345", "67890").Once().Return(networkError)
+	next.On("RemoveFilm", mock.Anything, "12345", "67890").Once().Return(errors.New(NOT_FOUND_ERROR_MESSAGE))
+
+	err := NewRetryPlanetsDao(next, testRetry).RemoveFilm(context.Background(), "12345", "67890")
+
+	assert.NoError(t, err)
+	next.AssertExpectations(t)
+
+	next = &mocks.PlanetsDAO{}
+	next.On("RemoveFilm", mock.Anything, "12345", "67890").Once().Return(errors.New("server selection error: server selection timeout"))
+	next.On("RemoveFilm", mock.Anything, "12345", "67890").Once().Return(errors.New(NOT_FOUND_ERROR_MESSAGE))
+
+	err = NewRetryPlanetsDao(next, testRetry).RemoveFilm(context.Background(), "12345", "67890")
+
+	assert.EqualError(t, err, NOT_FOUND_ERROR_MESSAGE)
+	next.AssertExpectations(t)
+}
+
+func Test_retryPlanetsDAO_UpsertByName(t *testing.T) {
+	next := &mocks.PlanetsDAO{}
+	next.On("UpsertByName", mock.Anything, mock.Anything).Once().Return(steppedDown)
+
+	err := NewRetryPlanetsDao(next, testRetry).UpsertByName(context.Background(), &models.Planet{Name: "Hoth"})
+
+	assert.Equal(t, steppedDown, err)
+	next.AssertExpectations(t)
+}
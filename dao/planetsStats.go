@@ -0,0 +1,133 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	GROUP_BY_CLIMATE = "climate"
+	GROUP_BY_TERRAIN = "terrain"
+	GROUP_BY_FILMS   = "films"
+)
+
+const (
+	INVALID_GROUP_BY_ERROR_MESSAGE = "Invalid groupBy parameter"
+)
+
+type filmsSummaryResult struct {
+	Total int     `bson:"total"`
+	Avg   float64 `bson:"avg"`
+	Min   int     `bson:"min"`
+	Max   int     `bson:"max"`
+}
+
+func (pd *planetsDAO) Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (*models.PlanetStats, error) {
+	if groupBy == "" {
+		groupBy = GROUP_BY_CLIMATE
+	}
+	groupStages, err := groupByStages(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	match := bson.D{{Key: "$match", Value: statsMatch(filter)}}
+
+	var summaries []filmsSummaryResult
+	summaryPipeline := mongo.Pipeline{match, filmsSummaryStage()}
+	if err := pd.aggregate(ctx, summaryPipeline, &summaries); err != nil {
+		return nil, err
+	}
+
+	groups := []models.StatsGroup{}
+	groupPipeline := append(mongo.Pipeline{match}, groupStages...)
+	if err := pd.aggregate(ctx, groupPipeline, &groups); err != nil {
+		return nil, err
+	}
+
+	stats := &models.PlanetStats{GroupBy: groupBy, Groups: groups}
+	if len(summaries) > 0 {
+		summary := summaries[0]
+		stats.Total = summary.Total
+		stats.Films = models.FilmsSummary{Avg: summary.Avg, Min: summary.Min, Max: summary.Max}
+	}
+	return stats, nil
+}
+
+func (pd *planetsDAO) aggregate(ctx context.Context, pipeline mongo.Pipeline, result interface{}) error {
+	cursor, err := pd.db.Collection(COLLECTION).Aggregate(ctx, pipeline)
+	if err != nil {
+		logging.FromContext(ctx).WithField("pipeline", pipeline).Error("There was an error aggregating the planets::", err.Error())
+		return err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, result); err != nil {
+		logging.FromContext(ctx).Error(err)
+		return err
+	}
+	return nil
+}
+
+func statsMatch(filter models.StatsFilter) bson.M {
+	match := bson.M{}
+	if filter.Name != "" {
+		match["name"] = primitive.Regex{Pattern: filter.Name, Options: "i"}
+	}
+	if filter.Climate != "" {
+		match["climate"] = models.NormalizeListValue(filter.Climate)
+	}
+	if filter.Terrain != "" {
+		match["terrain"] = models.NormalizeListValue(filter.Terrain)
+	}
+	films := bson.M{}
+	if filter.MinFilms != nil {
+		films["$gte"] = *filter.MinFilms
+	}
+	if filter.MaxFilms != nil {
+		films["$lte"] = *filter.MaxFilms
+	}
+	if len(films) > 0 {
+		match["films"] = films
+	}
+	return match
+}
+
+func filmsSummaryStage() bson.D {
+	return bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: nil},
+		{Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}},
+		{Key: "avg", Value: bson.D{{Key: "$avg", Value: "$films"}}},
+		{Key: "min", Value: bson.D{{Key: "$min", Value: "$films"}}},
+		{Key: "max", Value: bson.D{{Key: "$max", Value: "$films"}}},
+	}}}
+}
+
+func groupByStages(groupBy string) (mongo.Pipeline, error) {
+	switch groupBy {
+	case GROUP_BY_CLIMATE, GROUP_BY_TERRAIN:
+		return mongo.Pipeline{
+			{{Key: "$unwind", Value: "$" + groupBy}},
+			countByStage("$" + groupBy),
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		}, nil
+	case GROUP_BY_FILMS:
+		return mongo.Pipeline{
+			countByStage("$films"),
+			{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		}, nil
+	default:
+		return nil, errors.New(INVALID_GROUP_BY_ERROR_MESSAGE)
+	}
+}
+
+func countByStage(key string) bson.D {
+	return bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: key},
+		{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+	}}}
+}
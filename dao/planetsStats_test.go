@@ -0,0 +1,106 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func Test_planetsDAO_Stats(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	summaryCursor := &mocks.CursorHelper{}
+	groupCursor := &mocks.CursorHelper{}
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Aggregate", context.Background(), mock.Anything).
+		Once().
+		Return(summaryCursor, nil)
+	collectionHelper.
+		On("Aggregate", context.Background(), mock.Anything).
+		Once().
+		Return(groupCursor, nil)
+
+	summaryCursor.On("Close", context.Background()).Return(nil)
+	summaryCursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]filmsSummaryResult)
+			*arg = []filmsSummaryResult{{Total: 3, Avg: 2, Min: 1, Max: 3}}
+		}).
+		Return(nil)
+
+	groupCursor.On("Close", context.Background()).Return(nil)
+	groupCursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.StatsGroup)
+			*arg = []models.StatsGroup{{Value: "temperate", Count: 2}, {Value: "arid", Count: 1}}
+		}).
+		Return(nil)
+
+	dao := NewPlanetsDao(dbHelper)
+	stats, err := dao.Stats(context.Background(), "", models.StatsFilter{})
+
+	expected := &models.PlanetStats{
+		GroupBy: GROUP_BY_CLIMATE,
+		Total:   3,
+		Films:   models.FilmsSummary{Avg: 2, Min: 1, Max: 3},
+		Groups:  []models.StatsGroup{{Value: "temperate", Count: 2}, {Value: "arid", Count: 1}},
+	}
+	assert.Equal(t, expected, stats)
+	assert.NoError(t, err)
+}
+
+func Test_planetsDAO_Stats_with_invalid_group_by(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+
+	dao := NewPlanetsDao(dbHelper)
+	stats, err := dao.Stats(context.Background(), "name", models.StatsFilter{})
+
+	assert.Nil(t, stats)
+	assert.EqualError(t, err, INVALID_GROUP_BY_ERROR_MESSAGE)
+}
+
+func Test_planetsDAO_Stats_with_error_on_aggregate(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbHelper.
+		On("Collection", "planets").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Aggregate", context.Background(), mock.Anything).
+		Once().
+		Return(nil, errors.New("mocked-error"))
+
+	dao := NewPlanetsDao(dbHelper)
+	stats, err := dao.Stats(context.Background(), GROUP_BY_FILMS, models.StatsFilter{})
+
+	assert.Nil(t, stats)
+	assert.EqualError(t, err, "mocked-error")
+}
+
+func Test_statsMatch(t *testing.T) {
+	minFilms, maxFilms := 1, 3
+	filter := models.StatsFilter{Climate: " Arid", MinFilms: &minFilms, MaxFilms: &maxFilms}
+
+	expected := bson.M{
+		"climate": "arid",
+		"films":   bson.M{"$gte": 1, "$lte": 3},
+	}
+	assert.Equal(t, expected, statsMatch(filter))
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/retry"
+)
+
+const (
+	UNAVAILABLE_ERROR_MESSAGE = "Database unavailable"
+)
+
+// WaitFor pings the database until it answers, up to cnf.Attempts times
+// with an exponential backoff, and returns the last error
+func WaitFor(ctx context.Context, client ClientHelper, cnf config.Startup) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = client.Ping(ctx); err == nil {
+			return nil
+		}
+		if attempt >= cnf.Attempts {
+			return err
+		}
+		delay := retry.Delay(cnf.Backoff, attempt-1)
+		log.WithError(err).WithField("attempt", attempt).Warn("The database didn't answer, retrying in ", delay)
+		if err := retry.Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Availability tells whether the server can use the database. A server
+// started in degraded mode is unavailable until Recover sees the database
+// answer and ran the OnRecover functions.
+type Availability struct {
+	client    ClientHelper
+	backoff   config.Backoff
+	available int32
+	mu        sync.Mutex
+	onRecover []func(ctx context.Context) error
+}
+
+func NewAvailability(client ClientHelper, backoff config.Backoff, available bool) *Availability {
+	a := &Availability{client: client, backoff: backoff}
+	if available {
+		a.available = 1
+	}
+	return a
+}
+
+func (a *Availability) Available() bool {
+	return atomic.LoadInt32(&a.available) == 1
+}
+
+// OnRecover registers a function to run once the database answers again,
+// before the server uses it, e.g. the pending migrations. The functions run
+// again on the next attempt when one of them fails, they must be idempotent.
+func (a *Availability) OnRecover(fn func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onRecover = append(a.onRecover, fn)
+}
+
+// Recover pings the database with the backoff until it answers and the
+// OnRecover functions succeed, or ctx is done, then makes it available
+func (a *Availability) Recover(ctx context.Context) error {
+	for attempt := 0; !a.Available(); attempt++ {
+		if err := retry.Sleep(ctx, retry.Delay(a.backoff, attempt)); err != nil {
+			return err
+		}
+		if err := a.client.Ping(ctx); err != nil {
+			log.WithError(err).WithField("attempt", attempt+1).Debug("The database is still unavailable")
+			continue
+		}
+		if err := a.runOnRecover(ctx); err != nil {
+			log.WithError(err).WithField("attempt", attempt+1).Warn("The database answered but couldn't be prepared, retrying")
+			continue
+		}
+		log.Info("The database is available again")
+		atomic.StoreInt32(&a.available, 1)
+	}
+	return nil
+}
+
+func (a *Availability) runOnRecover(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, fn := range a.onRecover {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check is the readiness check of the database, failing while it is unavailable
+func (a *Availability) Check(ctx context.Context) error {
+	if !a.Available() {
+		return errors.New(UNAVAILABLE_ERROR_MESSAGE)
+	}
+	return a.client.Ping(ctx)
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// flakyClient fails its first pings
+type flakyClient struct {
+	ClientHelper
+	failures int
+	pings    int
+}
+
+func (c *flakyClient) Ping(ctx context.Context) error {
+	c.pings++
+	if c.pings <= c.failures {
+		return errors.New("mocked-error")
+	}
+	return nil
+}
+
+var testBackoff = config.Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond}
+
+func TestWaitFor(t *testing.T) {
+	client := &flakyClient{ClientHelper: NewMemoryClient(), failures: 2}
+
+	err := WaitFor(context.Background(), client, config.Startup{Attempts: 3, Backoff: testBackoff})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, client.pings)
+}
+
+func TestWaitFor_gives_up(t *testing.T) {
+	client := &flakyClient{ClientHelper: NewMemoryClient(), failures: 5}
+
+	err := WaitFor(context.Background(), client, config.Startup{Attempts: 2, Backoff: testBackoff})
+
+	assert.EqualError(t, err, "mocked-error")
+	assert.Equal(t, 2, client.pings)
+
+	client.pings = 0
+	err = WaitFor(context.Background(), client, config.Startup{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, client.pings)
+}
+
+func TestAvailability_Recover(t *testing.T) {
+	client := &flakyClient{ClientHelper: NewMemoryClient(), failures: 2}
+	availability := NewAvailability(client, testBackoff, false)
+	var availableOnRecover bool
+	availability.OnRecover(func(ctx context.Context) error {
+		availableOnRecover = availability.Available()
+		return nil
+	})
+
+	assert.EqualError(t, availability.Check(context.Background()), UNAVAILABLE_ERROR_MESSAGE)
+	assert.NoError(t, availability.Recover(context.Background()))
+
+	assert.True(t, availability.Available())
+	assert.False(t, availableOnRecover)
+	assert.Equal(t, 3, client.pings)
+	assert.NoError(t, availability.Check(context.Background()))
+}
+
+func TestAvailability_Recover_failing_OnRecover(t *testing.T) {
+	client := &flakyClient{ClientHelper: NewMemoryClient()}
+	availability := NewAvailability(client, testBackoff, false)
+	calls := 0
+	availability.OnRecover(func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("mocked-error")
+		}
+		return nil
+	})
+
+	assert.NoError(t, availability.Recover(context.Background()))
+
+	assert.True(t, availability.Available())
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 3, client.pings)
+}
+
+func TestAvailability_Recover_canceled(t *testing.T) {
+	availability := NewAvailability(&flakyClient{ClientHelper: NewMemoryClient(), failures: 1000}, testBackoff, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, availability.Recover(ctx))
+	assert.False(t, availability.Available())
+}
@@ -0,0 +1,279 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	boltOpenTimeout = time.Second
+)
+
+// BoltDatabaseHelper is implemented by the databases stored in a bolt file,
+// it lets a DAO work with the buckets directly instead of going through the query engine
+type BoltDatabaseHelper interface {
+	DatabaseHelper
+	Store() *bbolt.DB
+	Bucket(colName string) []byte
+}
+
+// boltClient keeps every collection in a bucket of a single bolt file.
+// Documents are stored as bson keyed by their _id, each write is a transaction
+// synced to disk, so a crash never leaves a partially written document.
+type boltClient struct {
+	store *bbolt.DB
+}
+
+type boltDatabase struct {
+	client *boltClient
+	name   string
+}
+
+type boltCollection struct {
+	db   *boltDatabase
+	name string
+}
+
+// NewBoltClient opens (or creates) the bolt file at path
+func NewBoltClient(path string) (ClientHelper, error) {
+	store, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+	return &boltClient{store: store}, nil
+}
+
+// BoltKey returns the key a document with the given _id is stored under
+func BoltKey(id interface{}) ([]byte, error) {
+	if objectID, ok := id.(primitive.ObjectID); ok {
+		return objectID[:], nil
+	}
+	return bson.Marshal(bson.D{{Key: "_id", Value: id}})
+}
+
+// Disconnect closes the bolt file, waiting for the running transactions
+func (bc *boltClient) Disconnect(ctx context.Context) error {
+	return bc.store.Close()
+}
+
+// Ping fails once the file has been closed
+func (bc *boltClient) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return bc.store.View(func(tx *bbolt.Tx) error { return nil })
+}
+
+func (bc *boltClient) Database(dbName string) DatabaseHelper {
+	return &boltDatabase{client: bc, name: dbName}
+}
+
+func (bd *boltDatabase) Collection(colName string) CollectionHelper {
+	return &boltCollection{db: bd, name: colName}
+}
+
+func (bd *boltDatabase) Client() ClientHelper {
+	return bd.client
+}
+
+func (bd *boltDatabase) Store() *bbolt.DB {
+	return bd.client.store
+}
+
+func (bd *boltDatabase) Bucket(colName string) []byte {
+	return []byte(bd.name + "." + colName)
+}
+
+func (bd *boltDatabase) documents(colName string) ([]bson.D, error) {
+	docs := []bson.D{}
+	err := bd.Store().View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bd.Bucket(colName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var doc bson.D
+			if err := bson.Unmarshal(append([]byte{}, v...), &doc); err != nil {
+				return err
+			}
+			docs = append(docs, doc)
+			return nil
+		})
+	})
+	return docs, err
+}
+
+func (bc *boltCollection) bucket() []byte {
+	return bc.db.Bucket(bc.name)
+}
+
+func (bc *boltCollection) Find(ctx context.Context, filter interface{}) (CursorHelper, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := bc.db.documents(bc.name)
+	if err != nil {
+		return nil, err
+	}
+	if docs, err = filterDocuments(docs, query); err != nil {
+		return nil, err
+	}
+	return &memoryCursor{docs: docs}, nil
+}
+
+func (bc *boltCollection) FindOne(ctx context.Context, filter interface{}) SingleResultHelper {
+	cursor, err := bc.Find(ctx, filter)
+	if err != nil {
+		return &memorySingleResult{err: err}
+	}
+	docs := cursor.(*memoryCursor).docs
+	if len(docs) == 0 {
+		return &memorySingleResult{err: mongo.ErrNoDocuments}
+	}
+	return &memorySingleResult{doc: docs[0]}
+}
+
+func (bc *boltCollection) InsertOne(ctx context.Context, document interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := toDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := lookup(doc, "_id")
+	if !ok {
+		id = primitive.NewObjectID()
+		doc = append(bson.D{{Key: "_id", Value: id}}, doc...)
+	}
+	key, err := BoltKey(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = bc.db.Store().Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bc.bucket())
+		if err != nil {
+			return err
+		}
+		if bucket.Get(key) != nil {
+			return duplicateKeyError()
+		}
+		return bucket.Put(key, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func (bc *boltCollection) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &mongo.DeleteResult{}
+	err = bc.db.Store().Update(func(tx *bbolt.Tx) error {
+		key, _, err := bc.findFirst(tx, query)
+		if err != nil || key == nil {
+			return err
+		}
+		result.DeletedCount = 1
+		return tx.Bucket(bc.bucket()).Delete(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (bc *boltCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	result := &mongo.UpdateResult{}
+	err = bc.db.Store().Update(func(tx *bbolt.Tx) error {
+		key, doc, err := bc.findFirst(tx, query)
+		if err != nil || key == nil {
+			return err
+		}
+		updated, err := applyUpdate(doc, changes)
+		if err != nil {
+			return err
+		}
+		data, err := bson.Marshal(updated)
+		if err != nil {
+			return err
+		}
+		result.MatchedCount, result.ModifiedCount = 1, 1
+		return tx.Bucket(bc.bucket()).Put(key, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findFirst returns the key and document of the first match, or a nil key
+func (bc *boltCollection) findFirst(tx *bbolt.Tx, query bson.D) ([]byte, bson.D, error) {
+	bucket := tx.Bucket(bc.bucket())
+	if bucket == nil {
+		return nil, nil, nil
+	}
+	cursor := bucket.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var doc bson.D
+		if err := bson.Unmarshal(append([]byte{}, v...), &doc); err != nil {
+			return nil, nil, err
+		}
+		ok, err := matches(doc, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			return append([]byte{}, k...), doc, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (bc *boltCollection) Aggregate(ctx context.Context, pipeline interface{}) (CursorHelper, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stages, err := toArray(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := bc.db.documents(bc.name)
+	if err != nil {
+		return nil, err
+	}
+	if docs, err = aggregate(bc.db, docs, stages); err != nil {
+		return nil, err
+	}
+	return &memoryCursor{docs: docs}, nil
+}
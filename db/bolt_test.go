@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func openBoltDatabase(t *testing.T, path string) (*boltClient, DatabaseHelper) {
+	client, err := NewBoltClient(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.(*boltClient), client.Database("test")
+}
+
+func Test_boltCollection_survives_reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	client, database := openBoltDatabase(t, path)
+	planets := database.Collection("planets")
+
+	_, err := planets.InsertOne(context.Background(), bson.M{"_id": "hoth", "name": "Hoth", "films": 1})
+	assert.NoError(t, err)
+	_, err = planets.InsertOne(context.Background(), bson.M{"_id": "naboo", "name": "Naboo"})
+	assert.NoError(t, err)
+	_, err = planets.UpdateOne(context.Background(), bson.M{"_id": "hoth"}, bson.M{"$inc": bson.M{"films": 1}})
+	assert.NoError(t, err)
+	_, err = planets.DeleteOne(context.Background(), bson.M{"name": "Naboo"})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Disconnect(context.Background()))
+
+	client, database = openBoltDatabase(t, path)
+	defer client.Disconnect(context.Background())
+
+	var planet struct {
+		Name  string `bson:"name"`
+		Films int    `bson:"films"`
+	}
+	assert.NoError(t, database.Collection("planets").FindOne(context.Background(), bson.M{"_id": "hoth"}).Decode(&planet))
+	assert.Equal(t, 2, planet.Films)
+	err = database.Collection("planets").FindOne(context.Background(), bson.M{"_id": "naboo"}).Decode(&planet)
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+}
+
+func Test_boltCollection_InsertOne_with_duplicated_id(t *testing.T) {
+	client, database := openBoltDatabase(t, filepath.Join(t.TempDir(), "test.db"))
+	defer client.Disconnect(context.Background())
+
+	_, err := database.Collection("planets").InsertOne(context.Background(), bson.M{"_id": "hoth"})
+	assert.NoError(t, err)
+	_, err = database.Collection("planets").InsertOne(context.Background(), bson.M{"_id": "hoth"})
+
+	writeException, ok := err.(mongo.WriteException)
+	assert.True(t, ok)
+	assert.Equal(t, duplicateKeyErrorCode, writeException.WriteErrors[0].Code)
+}
+
+func Test_boltCollection_Aggregate_with_lookup(t *testing.T) {
+	client, database := openBoltDatabase(t, filepath.Join(t.TempDir(), "test.db"))
+	defer client.Disconnect(context.Background())
+
+	database.Collection("planets").InsertOne(context.Background(), bson.M{"_id": "tatooine"})
+	database.Collection("people").InsertOne(context.Background(), bson.M{"name": "Luke", "homeworld": "tatooine"})
+
+	cursor, err := database.Collection("planets").Aggregate(context.Background(), mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "people"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "homeworld"},
+			{Key: "as", Value: "residents"},
+		}}},
+	})
+	assert.NoError(t, err)
+	var planets []struct {
+		Residents []bson.M `bson:"residents"`
+	}
+	assert.NoError(t, cursor.All(context.Background(), &planets))
+	assert.Len(t, planets, 1)
+	assert.Len(t, planets[0].Residents, 1)
+}
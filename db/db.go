@@ -3,11 +3,13 @@ package db
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/wallacebenevides/star-wars-api/config"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type DatabaseHelper interface {
@@ -19,7 +21,16 @@ type CollectionHelper interface {
 	FindOne(ctx context.Context, filter interface{}) SingleResultHelper
 	InsertOne(ctx context.Context, document interface{}) (interface{}, error)
 	DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error)
 	Find(ctx context.Context, filter interface{}) (CursorHelper, error)
+	Aggregate(ctx context.Context, pipeline interface{}) (CursorHelper, error)
+}
+
+// IndexHelper is implemented by the collections of the mongo driver, the
+// in-process drivers scan their documents and have no indexes
+type IndexHelper interface {
+	CreateIndex(ctx context.Context, keys interface{}, unique bool) (string, error)
+	DropIndex(ctx context.Context, name string) error
 }
 
 type SingleResultHelper interface {
@@ -28,6 +39,8 @@ type SingleResultHelper interface {
 
 type ClientHelper interface {
 	Database(string) DatabaseHelper
+	Disconnect(ctx context.Context) error
+	Ping(ctx context.Context) error
 }
 
 type CursorHelper interface {
@@ -64,18 +77,17 @@ type mongoSingleResult struct {
 }
 
 func NewClient(cnf *config.Database) (ClientHelper, error) {
-	log.Println("initializing a session with db ", cnf.DatabaseName, cnf.Uri)
-	clientOptions := options.Client().ApplyURI(cnf.Uri)
-	client, err := mongo.Connect(context.TODO(), clientOptions)
-
+	log.Println("initializing a session with db ", cnf.DatabaseName, cnf.Redacted().Uri)
+	clientOptions, err := clientOptions(cnf)
 	if err != nil {
 		return nil, err
 	}
-	// Check the connection
-	if err = client.Ping(context.TODO(), nil); err != nil {
+	// the connections are opened in the background, WaitFor checks the server answers
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
 		return nil, err
 	}
-	return &mongoClient{cl: client}, err
+	return &mongoClient{cl: client}, nil
 }
 
 func NewDatabase(cnf *config.Database, client ClientHelper) DatabaseHelper {
@@ -91,6 +103,14 @@ func (mc *mongoClient) Database(dbName string) DatabaseHelper {
 	return &mongoDatabase{db: db}
 }
 
+func (mc *mongoClient) Disconnect(ctx context.Context) error {
+	return mc.cl.Disconnect(ctx)
+}
+
+func (mc *mongoClient) Ping(ctx context.Context) error {
+	return mc.cl.Ping(ctx, readpref.Primary())
+}
+
 func (md *mongoDatabase) Collection(colName string) CollectionHelper {
 	collection := md.db.Collection(colName)
 	return &mongoCollection{coll: collection}
@@ -102,18 +122,38 @@ func (md *mongoDatabase) Client() ClientHelper {
 }
 
 func (mc *mongoCollection) Find(ctx context.Context, filter interface{}) (CursorHelper, error) {
-	cursor, err := mc.coll.Find(ctx, filter)
+	findOptions := options.Find()
+	if maxTime, ok := maxTime(ctx); ok {
+		findOptions.SetMaxTime(maxTime)
+	}
+	cursor, err := mc.coll.Find(ctx, filter, findOptions)
+	return cursor, err
+}
+
+func (mc *mongoCollection) Aggregate(ctx context.Context, pipeline interface{}) (CursorHelper, error) {
+	aggregateOptions := options.Aggregate()
+	if maxTime, ok := maxTime(ctx); ok {
+		aggregateOptions.SetMaxTime(maxTime)
+	}
+	cursor, err := mc.coll.Aggregate(ctx, pipeline, aggregateOptions)
 	return cursor, err
 }
 
 func (mc *mongoCollection) FindOne(ctx context.Context, filter interface{}) SingleResultHelper {
-	singleResult := mc.coll.FindOne(ctx, filter)
+	findOneOptions := options.FindOne()
+	if maxTime, ok := maxTime(ctx); ok {
+		findOneOptions.SetMaxTime(maxTime)
+	}
+	singleResult := mc.coll.FindOne(ctx, filter, findOneOptions)
 	return &mongoSingleResult{sr: singleResult}
 }
 
 func (mc *mongoCollection) InsertOne(ctx context.Context, document interface{}) (interface{}, error) {
 	id, err := mc.coll.InsertOne(ctx, document)
-	return id.InsertedID, err
+	if err != nil {
+		return nil, err
+	}
+	return id.InsertedID, nil
 }
 
 func (mc *mongoCollection) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
@@ -121,6 +161,20 @@ func (mc *mongoCollection) DeleteOne(ctx context.Context, filter interface{}) (*
 	return deleteResult, err
 }
 
+func (mc *mongoCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	updateResult, err := mc.coll.UpdateOne(ctx, filter, update)
+	return updateResult, err
+}
+
+func (mc *mongoCollection) CreateIndex(ctx context.Context, keys interface{}, unique bool) (string, error) {
+	return mc.coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys, Options: options.Index().SetUnique(unique)})
+}
+
+func (mc *mongoCollection) DropIndex(ctx context.Context, name string) error {
+	_, err := mc.coll.Indexes().DropOne(ctx, name)
+	return err
+}
+
 func (sr *mongoSingleResult) Decode(v interface{}) error {
 	return sr.sr.Decode(v)
 }
@@ -140,6 +194,21 @@ func (cs *mongoCursor) Next(ctx context.Context) bool {
 	return cs.Next(ctx)
 }
 
+// maxTime is the time left before the context deadline, sent to mongo as maxTimeMS
+// so the server stops the query instead of running it after the client gave up
+func maxTime(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := time.Until(deadline)
+	if remaining < time.Millisecond {
+		// maxTimeMS 0 means no limit, the driver fails the expired context anyway
+		remaining = time.Millisecond
+	}
+	return remaining, true
+}
+
 func (id *mongoObjectId) NewObjectID() primitive.ObjectID {
 	return primitive.NewObjectID()
 }
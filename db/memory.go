@@ -0,0 +1,278 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	duplicateKeyErrorCode = 11000
+)
+
+// memoryClient keeps every database in memory, it's meant for local development and tests
+type memoryClient struct {
+	mu        sync.Mutex
+	databases map[string]*memoryDatabase
+}
+
+type memoryDatabase struct {
+	client      *memoryClient
+	mu          sync.Mutex
+	collections map[string]*memoryCollection
+}
+
+type memoryCollection struct {
+	db   *memoryDatabase
+	mu   sync.RWMutex
+	docs []bson.D
+}
+
+type memoryCursor struct {
+	docs    []bson.D
+	current bson.D
+}
+
+type memorySingleResult struct {
+	doc bson.D
+	err error
+}
+
+// NewMemoryClient returns a client whose databases live in the process memory.
+// It understands the filters, updates and aggregation stages used by the DAOs.
+func NewMemoryClient() ClientHelper {
+	return &memoryClient{databases: map[string]*memoryDatabase{}}
+}
+
+func (mc *memoryClient) Database(dbName string) DatabaseHelper {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	db, ok := mc.databases[dbName]
+	if !ok {
+		db = &memoryDatabase{client: mc, collections: map[string]*memoryCollection{}}
+		mc.databases[dbName] = db
+	}
+	return db
+}
+
+// Disconnect has nothing to release, the data stays available until the process exits
+func (mc *memoryClient) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (mc *memoryClient) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (md *memoryDatabase) Collection(colName string) CollectionHelper {
+	return md.collection(colName)
+}
+
+func (md *memoryDatabase) Client() ClientHelper {
+	return md.client
+}
+
+func (md *memoryDatabase) collection(colName string) *memoryCollection {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	collection, ok := md.collections[colName]
+	if !ok {
+		collection = &memoryCollection{db: md}
+		md.collections[colName] = collection
+	}
+	return collection
+}
+
+func (md *memoryDatabase) documents(colName string) ([]bson.D, error) {
+	return md.collection(colName).snapshot(), nil
+}
+
+// snapshot returns the documents of the collection, updates replace documents
+// instead of changing them so the snapshot can be read without the lock
+func (mc *memoryCollection) snapshot() []bson.D {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return append([]bson.D{}, mc.docs...)
+}
+
+func (mc *memoryCollection) Find(ctx context.Context, filter interface{}) (CursorHelper, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := filterDocuments(mc.snapshot(), query)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryCursor{docs: docs}, nil
+}
+
+func (mc *memoryCollection) FindOne(ctx context.Context, filter interface{}) SingleResultHelper {
+	cursor, err := mc.Find(ctx, filter)
+	if err != nil {
+		return &memorySingleResult{err: err}
+	}
+	docs := cursor.(*memoryCursor).docs
+	if len(docs) == 0 {
+		return &memorySingleResult{err: mongo.ErrNoDocuments}
+	}
+	return &memorySingleResult{doc: docs[0]}
+}
+
+func (mc *memoryCollection) InsertOne(ctx context.Context, document interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := toDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := lookup(doc, "_id")
+	if !ok {
+		id = primitive.NewObjectID()
+		doc = append(bson.D{{Key: "_id", Value: id}}, doc...)
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for _, existing := range mc.docs {
+		if existingID, _ := lookup(existing, "_id"); equalValues(existingID, id) {
+			return nil, duplicateKeyError()
+		}
+	}
+	mc.docs = append(mc.docs, doc)
+	return id, nil
+}
+
+func (mc *memoryCollection) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for i, doc := range mc.docs {
+		ok, err := matches(doc, query)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			mc.docs = append(mc.docs[:i:i], mc.docs[i+1:]...)
+			return &mongo.DeleteResult{DeletedCount: 1}, nil
+		}
+	}
+	return &mongo.DeleteResult{DeletedCount: 0}, nil
+}
+
+func (mc *memoryCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for i, doc := range mc.docs {
+		ok, err := matches(doc, query)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		updated, err := applyUpdate(doc, changes)
+		if err != nil {
+			return nil, err
+		}
+		mc.docs[i] = updated
+		return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+	}
+	return &mongo.UpdateResult{}, nil
+}
+
+func (mc *memoryCollection) Aggregate(ctx context.Context, pipeline interface{}) (CursorHelper, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stages, err := toArray(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := aggregate(mc.db, mc.snapshot(), stages)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryCursor{docs: docs}, nil
+}
+
+func (cs *memoryCursor) All(ctx context.Context, v interface{}) error {
+	values := bson.A{}
+	for _, doc := range cs.docs {
+		values = append(values, doc)
+	}
+	cs.docs = nil
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: values}})
+	if err != nil {
+		return err
+	}
+	return bson.Raw(data).Lookup("v").Unmarshal(v)
+}
+
+func (cs *memoryCursor) Close(ctx context.Context) error {
+	cs.docs = nil
+	return nil
+}
+
+func (cs *memoryCursor) Decode(v interface{}) error {
+	if cs.current == nil {
+		return errors.New("cursor has no current document")
+	}
+	return decodeDocument(cs.current, v)
+}
+
+func (cs *memoryCursor) Next(ctx context.Context) bool {
+	if len(cs.docs) == 0 {
+		cs.current = nil
+		return false
+	}
+	cs.current, cs.docs = cs.docs[0], cs.docs[1:]
+	return true
+}
+
+func (sr *memorySingleResult) Decode(v interface{}) error {
+	if sr.err != nil {
+		return sr.err
+	}
+	return decodeDocument(sr.doc, v)
+}
+
+func decodeDocument(doc bson.D, v interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, v)
+}
+
+// duplicateKeyError mimics the error mongo returns when an _id is reused
+func duplicateKeyError() error {
+	return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+		Code:    duplicateKeyErrorCode,
+		Message: "E11000 duplicate key error",
+	}}}
+}
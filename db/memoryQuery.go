@@ -0,0 +1,997 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toDocument normalizes a filter, update or document into bson.D values
+// (primitive.D, primitive.A, int32, primitive.ObjectID...) by round tripping it through bson
+func toDocument(value interface{}) (bson.D, error) {
+	normalized, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	switch doc := normalized.(type) {
+	case bson.D:
+		return doc, nil
+	case nil:
+		return bson.D{}, nil
+	default:
+		return nil, fmt.Errorf("expected a document, got %T", value)
+	}
+}
+
+func toArray(value interface{}) (bson.A, error) {
+	normalized, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	array, ok := normalized.(bson.A)
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", value)
+	}
+	return array, nil
+}
+
+func normalize(value interface{}) (interface{}, error) {
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: value}})
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc[0].Value, nil
+}
+
+// lookup reads a dotted path such as "residents.name" from a document
+func lookup(doc bson.D, path string) (interface{}, bool) {
+	key, rest := path, ""
+	if i := strings.Index(path, "."); i >= 0 {
+		key, rest = path[:i], path[i+1:]
+	}
+	for _, e := range doc {
+		if e.Key != key {
+			continue
+		}
+		if rest == "" {
+			return e.Value, true
+		}
+		switch value := e.Value.(type) {
+		case bson.D:
+			return lookup(value, rest)
+		case bson.A:
+			values := bson.A{}
+			for _, item := range value {
+				if sub, ok := item.(bson.D); ok {
+					if v, ok := lookup(sub, rest); ok {
+						values = append(values, v)
+					}
+				}
+			}
+			return values, true
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// set returns a copy of doc with the top level key set to value
+func set(doc bson.D, key string, value interface{}) bson.D {
+	result := make(bson.D, 0, len(doc)+1)
+	found := false
+	for _, e := range doc {
+		if e.Key == key {
+			result = append(result, bson.E{Key: key, Value: value})
+			found = true
+			continue
+		}
+		result = append(result, e)
+	}
+	if !found {
+		result = append(result, bson.E{Key: key, Value: value})
+	}
+	return result
+}
+
+func unset(doc bson.D, key string) bson.D {
+	result := make(bson.D, 0, len(doc))
+	for _, e := range doc {
+		if e.Key != key {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func filterDocuments(docs []bson.D, filter bson.D) ([]bson.D, error) {
+	result := []bson.D{}
+	for _, doc := range docs {
+		ok, err := matches(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+func matches(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		var ok bool
+		var err error
+		switch e.Key {
+		case "":
+			// bson.D{{}} is used as "match everything"
+			continue
+		case "$and", "$or", "$nor":
+			ok, err = matchesLogical(doc, e.Key, e.Value)
+		default:
+			value, exists := lookup(doc, e.Key)
+			ok, err = matchesCondition(value, exists, e.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchesLogical(doc bson.D, operator string, value interface{}) (bool, error) {
+	clauses, ok := value.(bson.A)
+	if !ok {
+		return false, fmt.Errorf("%s needs an array", operator)
+	}
+	for _, clause := range clauses {
+		sub, ok := clause.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("%s needs an array of documents", operator)
+		}
+		match, err := matches(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case operator == "$and" && !match:
+			return false, nil
+		case operator == "$or" && match:
+			return true, nil
+		case operator == "$nor" && match:
+			return false, nil
+		}
+	}
+	return operator != "$or", nil
+}
+
+func isOperatorDocument(value interface{}) bool {
+	doc, ok := value.(bson.D)
+	return ok && len(doc) > 0 && strings.HasPrefix(doc[0].Key, "$")
+}
+
+func matchesCondition(value interface{}, exists bool, condition interface{}) (bool, error) {
+	if !isOperatorDocument(condition) {
+		return matchesEquality(value, condition), nil
+	}
+	operators := condition.(bson.D)
+	for _, op := range operators {
+		ok, err := matchesOperator(value, exists, op, operators)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchesOperator(value interface{}, exists bool, op bson.E, operators bson.D) (bool, error) {
+	switch op.Key {
+	case "$eq":
+		return matchesEquality(value, op.Value), nil
+	case "$ne":
+		return !matchesEquality(value, op.Value), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		return anyValue(value, func(v interface{}) bool {
+			c, ok := compareValues(v, op.Value)
+			if !ok {
+				return false
+			}
+			switch op.Key {
+			case "$gt":
+				return c > 0
+			case "$gte":
+				return c >= 0
+			case "$lt":
+				return c < 0
+			default:
+				return c <= 0
+			}
+		}), nil
+	case "$in", "$nin":
+		candidates, ok := op.Value.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("%s needs an array", op.Key)
+		}
+		found := false
+		for _, candidate := range candidates {
+			if matchesEquality(value, candidate) {
+				found = true
+				break
+			}
+		}
+		return found == (op.Key == "$in"), nil
+	case "$exists":
+		return exists == truthy(op.Value), nil
+	case "$type":
+		return matchesType(value, exists, op.Value), nil
+	case "$regex":
+		options := ""
+		for _, o := range operators {
+			if o.Key == "$options" {
+				options, _ = o.Value.(string)
+			}
+		}
+		pattern, _ := op.Value.(string)
+		if regex, ok := op.Value.(primitive.Regex); ok {
+			pattern, options = regex.Pattern, regex.Options
+		}
+		return matchesEquality(value, primitive.Regex{Pattern: pattern, Options: options}), nil
+	case "$options":
+		return true, nil
+	case "$not":
+		ok, err := matchesCondition(value, exists, op.Value)
+		return !ok, err
+	case "$size":
+		array, ok := value.(bson.A)
+		size, isNumber := toFloat(op.Value)
+		return ok && isNumber && float64(len(array)) == size, nil
+	default:
+		return false, fmt.Errorf("unsupported query operator %s", op.Key)
+	}
+}
+
+// matchesEquality follows the mongo semantics: arrays match when any element matches
+func matchesEquality(value interface{}, condition interface{}) bool {
+	if regex, ok := condition.(primitive.Regex); ok {
+		re, err := compileRegex(regex)
+		if err != nil {
+			return false
+		}
+		return anyValue(value, func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && re.MatchString(s)
+		})
+	}
+	if equalValues(value, condition) {
+		return true
+	}
+	if array, ok := value.(bson.A); ok {
+		for _, item := range array {
+			if equalValues(item, condition) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyValue(value interface{}, predicate func(interface{}) bool) bool {
+	if array, ok := value.(bson.A); ok {
+		for _, item := range array {
+			if predicate(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return predicate(value)
+}
+
+func compileRegex(regex primitive.Regex) (*regexp.Regexp, error) {
+	flags := ""
+	for _, option := range regex.Options {
+		switch option {
+		case 'i', 'm', 's':
+			flags += string(option)
+		}
+	}
+	if flags != "" {
+		return regexp.Compile("(?" + flags + ")" + regex.Pattern)
+	}
+	return regexp.Compile(regex.Pattern)
+}
+
+func matchesType(value interface{}, exists bool, typeName interface{}) bool {
+	if !exists {
+		return false
+	}
+	name, _ := typeName.(string)
+	switch value.(type) {
+	case string:
+		return name == "string"
+	case bson.A:
+		return name == "array"
+	case bson.D:
+		return name == "object"
+	case primitive.ObjectID:
+		return name == "objectId"
+	case bool:
+		return name == "bool"
+	case nil, primitive.Null:
+		return name == "null"
+	case int32:
+		return name == "int" || name == "number"
+	case int64:
+		return name == "long" || name == "number"
+	case float64:
+		return name == "double" || name == "number"
+	case primitive.DateTime:
+		return name == "date"
+	case primitive.Regex:
+		return name == "regex"
+	}
+	return false
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case nil, primitive.Null:
+		return false
+	}
+	if f, ok := toFloat(value); ok {
+		return f != 0
+	}
+	return true
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	switch av := a.(type) {
+	case nil, primitive.Null:
+		switch b.(type) {
+		case nil, primitive.Null:
+			return true
+		}
+		return false
+	case bson.A:
+		bv, ok := b.(bson.A)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equalValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case bson.D:
+		bv, ok := b.(bson.D)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if av[i].Key != bv[i].Key || !equalValues(av[i].Value, bv[i].Value) {
+				return false
+			}
+		}
+		return true
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	}
+	return false
+}
+
+// compareValues orders two values of comparable kinds (numbers, strings, ids and dates)
+func compareValues(a, b interface{}) (int, bool) {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		return strings.Compare(av, bv), ok
+	case primitive.ObjectID:
+		bv, ok := b.(primitive.ObjectID)
+		return bytes.Compare(av[:], bv[:]), ok
+	case primitive.DateTime:
+		bv, ok := b.(primitive.DateTime)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sortOrder is used by $sort, values of different kinds are ordered as mongo does
+func sortOrder(a, b interface{}) int {
+	if c, ok := compareValues(a, b); ok {
+		return c
+	}
+	ra, rb := typeRank(a), typeRank(b)
+	switch {
+	case ra < rb:
+		return -1
+	case ra > rb:
+		return 1
+	}
+	return 0
+}
+
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil, primitive.Null:
+		return 1
+	case int32, int64, float64:
+		return 2
+	case string:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	}
+	return 10
+}
+
+func applyUpdate(doc bson.D, update bson.D) (bson.D, error) {
+	for _, op := range update {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s needs a document", op.Key)
+		}
+		for _, field := range fields {
+			switch op.Key {
+			case "$set":
+				doc = set(doc, field.Key, field.Value)
+			case "$unset":
+				doc = unset(doc, field.Key)
+			case "$inc":
+				current, _ := lookup(doc, field.Key)
+				sum, err := addNumbers(current, field.Value)
+				if err != nil {
+					return nil, err
+				}
+				doc = set(doc, field.Key, sum)
+			case "$addToSet", "$pull":
+				current, exists := lookup(doc, field.Key)
+				array, ok := current.(bson.A)
+				if exists && current != nil && !ok {
+					return nil, fmt.Errorf("can't apply %s to the non-array field %s", op.Key, field.Key)
+				}
+				doc = set(doc, field.Key, updateArray(array, op.Key, field.Value))
+			default:
+				return nil, fmt.Errorf("unsupported update operator %s", op.Key)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// updateArray adds the value to the array unless it holds it ($addToSet),
+// or removes the elements equal to the value ($pull)
+func updateArray(array bson.A, operator string, value interface{}) bson.A {
+	result := bson.A{}
+	found := false
+	for _, item := range array {
+		if equalValues(item, value) {
+			found = true
+			if operator == "$pull" {
+				continue
+			}
+		}
+		result = append(result, item)
+	}
+	if operator == "$addToSet" && !found {
+		result = append(result, value)
+	}
+	return result
+}
+
+func addNumbers(a, b interface{}) (interface{}, error) {
+	if a == nil {
+		a = int32(0)
+	}
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return nil, fmt.Errorf("can't add %v and %v", a, b)
+	}
+	return numberResult(af+bf, isInteger(a) && isInteger(b)), nil
+}
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int32, int64, int:
+		return true
+	}
+	return false
+}
+
+func numberResult(f float64, integer bool) interface{} {
+	if !integer {
+		return f
+	}
+	if f >= math.MinInt32 && f <= math.MaxInt32 {
+		return int32(f)
+	}
+	return int64(f)
+}
+
+// documentSource gives $lookup access to the other collections of a database
+type documentSource interface {
+	documents(collection string) ([]bson.D, error)
+}
+
+func aggregate(source documentSource, docs []bson.D, stages bson.A) ([]bson.D, error) {
+	for _, s := range stages {
+		stage, ok := s.(bson.D)
+		if !ok || len(stage) != 1 {
+			return nil, fmt.Errorf("invalid pipeline stage %v", s)
+		}
+		var err error
+		spec := stage[0].Value
+		switch stage[0].Key {
+		case "$match":
+			filter, _ := spec.(bson.D)
+			docs, err = filterDocuments(docs, filter)
+		case "$group":
+			docs, err = groupStage(docs, spec)
+		case "$unwind":
+			docs, err = unwindStage(docs, spec)
+		case "$sort":
+			docs, err = sortStage(docs, spec)
+		case "$project":
+			docs, err = projectStage(docs, spec)
+		case "$addFields", "$set":
+			docs, err = addFieldsStage(docs, spec)
+		case "$lookup":
+			docs, err = lookupStage(source, docs, spec)
+		case "$limit", "$skip":
+			n, ok := toFloat(spec)
+			if !ok {
+				return nil, fmt.Errorf("%s needs a number", stage[0].Key)
+			}
+			docs = sliceStage(docs, stage[0].Key, int(n))
+		case "$count":
+			field, _ := spec.(string)
+			docs = []bson.D{{{Key: field, Value: int32(len(docs))}}}
+		default:
+			return nil, fmt.Errorf("unsupported aggregation stage %s", stage[0].Key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func groupStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$group needs a document")
+	}
+	type group struct {
+		id     interface{}
+		values map[string][]interface{}
+	}
+	groups := []*group{}
+	byKey := map[string]*group{}
+	for _, doc := range docs {
+		var id interface{}
+		for _, f := range fields {
+			if f.Key == "_id" {
+				id = evaluate(f.Value, doc, nil)
+			}
+		}
+		key := fmt.Sprintf("%#v", id)
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{id: id, values: map[string][]interface{}{}}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		for _, f := range fields {
+			if f.Key == "_id" {
+				continue
+			}
+			accumulator, ok := f.Value.(bson.D)
+			if !ok || len(accumulator) != 1 {
+				return nil, fmt.Errorf("invalid accumulator for %s", f.Key)
+			}
+			g.values[f.Key] = append(g.values[f.Key], evaluate(accumulator[0].Value, doc, nil))
+		}
+	}
+	result := []bson.D{}
+	for _, g := range groups {
+		out := bson.D{{Key: "_id", Value: g.id}}
+		for _, f := range fields {
+			if f.Key == "_id" {
+				continue
+			}
+			value, err := accumulate(f.Value.(bson.D)[0].Key, g.values[f.Key])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, bson.E{Key: f.Key, Value: value})
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+func accumulate(operator string, values []interface{}) (interface{}, error) {
+	switch operator {
+	case "$sum", "$avg":
+		sum, count, integer := 0.0, 0, true
+		for _, v := range values {
+			if f, ok := toFloat(v); ok {
+				sum += f
+				count++
+				integer = integer && isInteger(v)
+			}
+		}
+		if operator == "$sum" {
+			return numberResult(sum, integer), nil
+		}
+		if count == 0 {
+			return nil, nil
+		}
+		return sum / float64(count), nil
+	case "$min", "$max":
+		var result interface{}
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+			if result == nil {
+				result = v
+				continue
+			}
+			c := sortOrder(v, result)
+			if (operator == "$min" && c < 0) || (operator == "$max" && c > 0) {
+				result = v
+			}
+		}
+		return result, nil
+	case "$first":
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values[0], nil
+	case "$push":
+		return bson.A(values), nil
+	case "$addToSet":
+		set := bson.A{}
+		for _, v := range values {
+			if !matchesEquality(set, v) {
+				set = append(set, v)
+			}
+		}
+		return set, nil
+	}
+	return nil, fmt.Errorf("unsupported accumulator %s", operator)
+}
+
+func unwindStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	path, preserve := "", false
+	switch s := spec.(type) {
+	case string:
+		path = s
+	case bson.D:
+		for _, e := range s {
+			switch e.Key {
+			case "path":
+				path, _ = e.Value.(string)
+			case "preserveNullAndEmptyArrays":
+				preserve = truthy(e.Value)
+			}
+		}
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("$unwind needs a field path")
+	}
+	field := path[1:]
+	result := []bson.D{}
+	for _, doc := range docs {
+		value, exists := lookup(doc, field)
+		array, isArray := value.(bson.A)
+		switch {
+		case !exists || value == nil || (isArray && len(array) == 0):
+			if preserve {
+				result = append(result, doc)
+			}
+		case isArray:
+			for _, item := range array {
+				result = append(result, set(doc, field, item))
+			}
+		default:
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+func sortStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	keys, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$sort needs a document")
+	}
+	sorted := append([]bson.D{}, docs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, key := range keys {
+			a, _ := lookup(sorted[i], key.Key)
+			b, _ := lookup(sorted[j], key.Key)
+			c := sortOrder(a, b)
+			if direction, _ := toFloat(key.Value); direction < 0 {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return sorted, nil
+}
+
+func projectStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$project needs a document")
+	}
+	exclusion := true
+	for _, f := range fields {
+		if f.Key != "_id" && !isExclusion(f.Value) {
+			exclusion = false
+		}
+	}
+	result := []bson.D{}
+	for _, doc := range docs {
+		var out bson.D
+		if exclusion {
+			out = doc
+			for _, f := range fields {
+				out = unset(out, f.Key)
+			}
+		} else {
+			out = bson.D{}
+			if id, ok := lookup(doc, "_id"); ok {
+				out = append(out, bson.E{Key: "_id", Value: id})
+			}
+			for _, f := range fields {
+				switch {
+				case f.Key == "_id" && isExclusion(f.Value):
+					out = unset(out, "_id")
+				case isInclusion(f.Value):
+					if value, ok := lookup(doc, f.Key); ok {
+						out = set(out, f.Key, value)
+					}
+				default:
+					out = set(out, f.Key, evaluate(f.Value, doc, nil))
+				}
+			}
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+func isExclusion(value interface{}) bool {
+	switch value.(type) {
+	case bool, int32, int64, float64:
+		return !truthy(value)
+	}
+	return false
+}
+
+func isInclusion(value interface{}) bool {
+	switch value.(type) {
+	case bool, int32, int64, float64:
+		return truthy(value)
+	}
+	return false
+}
+
+func addFieldsStage(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$addFields needs a document")
+	}
+	result := []bson.D{}
+	for _, doc := range docs {
+		out := doc
+		for _, f := range fields {
+			out = set(out, f.Key, evaluate(f.Value, doc, nil))
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+func lookupStage(source documentSource, docs []bson.D, spec interface{}) ([]bson.D, error) {
+	fields, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$lookup needs a document")
+	}
+	var from, localField, foreignField, as string
+	for _, f := range fields {
+		value, _ := f.Value.(string)
+		switch f.Key {
+		case "from":
+			from = value
+		case "localField":
+			localField = value
+		case "foreignField":
+			foreignField = value
+		case "as":
+			as = value
+		default:
+			return nil, fmt.Errorf("unsupported $lookup option %s", f.Key)
+		}
+	}
+	foreign, err := source.documents(from)
+	if err != nil {
+		return nil, err
+	}
+	result := []bson.D{}
+	for _, doc := range docs {
+		local, _ := lookup(doc, localField)
+		joined := bson.A{}
+		for _, other := range foreign {
+			value, _ := lookup(other, foreignField)
+			if lookupMatches(local, value) {
+				joined = append(joined, other)
+			}
+		}
+		result = append(result, set(doc, as, joined))
+	}
+	return result, nil
+}
+
+func lookupMatches(local, foreign interface{}) bool {
+	if array, ok := local.(bson.A); ok {
+		for _, item := range array {
+			if matchesEquality(foreign, item) {
+				return true
+			}
+		}
+		return false
+	}
+	return matchesEquality(foreign, local)
+}
+
+func sliceStage(docs []bson.D, stage string, n int) []bson.D {
+	if n > len(docs) {
+		n = len(docs)
+	}
+	if stage == "$limit" {
+		return docs[:n]
+	}
+	return docs[n:]
+}
+
+// evaluate computes an aggregation expression against a document, vars holds the $$ variables
+func evaluate(expr interface{}, doc bson.D, vars map[string]interface{}) interface{} {
+	switch e := expr.(type) {
+	case string:
+		if strings.HasPrefix(e, "$$") {
+			name, path := e[2:], ""
+			if i := strings.Index(name, "."); i >= 0 {
+				name, path = name[:i], name[i+1:]
+			}
+			value := vars[name]
+			if path == "" {
+				return value
+			}
+			if sub, ok := value.(bson.D); ok {
+				v, _ := lookup(sub, path)
+				return v
+			}
+			return nil
+		}
+		if strings.HasPrefix(e, "$") {
+			value, _ := lookup(doc, e[1:])
+			return value
+		}
+		return e
+	case bson.A:
+		values := bson.A{}
+		for _, item := range e {
+			values = append(values, evaluate(item, doc, vars))
+		}
+		return values
+	case bson.D:
+		if isOperatorDocument(e) && len(e) == 1 {
+			return evaluateOperator(e[0].Key, e[0].Value, doc, vars)
+		}
+		out := bson.D{}
+		for _, f := range e {
+			out = append(out, bson.E{Key: f.Key, Value: evaluate(f.Value, doc, vars)})
+		}
+		return out
+	}
+	return expr
+}
+
+// evaluateOperator computes the operators of the DAO pipelines, the others are nil
+func evaluateOperator(operator string, args interface{}, doc bson.D, vars map[string]interface{}) interface{} {
+	switch operator {
+	case "$map":
+		spec, _ := args.(bson.D)
+		var input, in interface{}
+		as := "this"
+		for _, f := range spec {
+			switch f.Key {
+			case "input":
+				input = evaluate(f.Value, doc, vars)
+			case "as":
+				as, _ = f.Value.(string)
+			case "in":
+				in = f.Value
+			}
+		}
+		array, _ := input.(bson.A)
+		result := bson.A{}
+		for _, item := range array {
+			scope := map[string]interface{}{}
+			for k, v := range vars {
+				scope[k] = v
+			}
+			scope[as] = item
+			result = append(result, evaluate(in, doc, scope))
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryPlanet struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Name    string             `bson:"name"`
+	Climate []string           `bson:"climate"`
+	Films   int                `bson:"films"`
+}
+
+func newMemoryCollection(t *testing.T, planets ...memoryPlanet) CollectionHelper {
+	collection := NewMemoryClient().Database("test").Collection("planets")
+	for _, planet := range planets {
+		_, err := collection.InsertOne(context.Background(), planet)
+		assert.NoError(t, err)
+	}
+	return collection
+}
+
+func findNames(t *testing.T, collection CollectionHelper, filter interface{}) []string {
+	cursor, err := collection.Find(context.Background(), filter)
+	assert.NoError(t, err)
+	var planets []memoryPlanet
+	assert.NoError(t, cursor.All(context.Background(), &planets))
+	names := []string{}
+	for _, planet := range planets {
+		names = append(names, planet.Name)
+	}
+	return names
+}
+
+func Test_memoryCollection_Find(t *testing.T) {
+	collection := newMemoryCollection(t,
+		memoryPlanet{Name: "Tatooine", Climate: []string{"arid"}, Films: 5},
+		memoryPlanet{Name: "Hoth", Climate: []string{"frozen"}, Films: 1},
+		memoryPlanet{Name: "Naboo", Climate: []string{"temperate"}, Films: 4},
+	)
+
+	tests := []struct {
+		name   string
+		filter interface{}
+		want   []string
+	}{
+		{"everything", bson.D{{}}, []string{"Tatooine", "Hoth", "Naboo"}},
+		{"equality", bson.M{"name": "Hoth"}, []string{"Hoth"}},
+		{"array element", bson.M{"climate": "arid"}, []string{"Tatooine"}},
+		{"regex with options", bson.D{{Key: "name", Value: primitive.Regex{Pattern: "^t", Options: "i"}}}, []string{"Tatooine"}},
+		{"comparison", bson.M{"films": bson.M{"$gte": 4}}, []string{"Tatooine", "Naboo"}},
+		{"in", bson.M{"name": bson.M{"$in": bson.A{"Hoth", "Naboo"}}}, []string{"Hoth", "Naboo"}},
+		{"or", bson.M{"$or": bson.A{bson.M{"name": "Hoth"}, bson.M{"films": 5}}}, []string{"Tatooine", "Hoth"}},
+		{"type", bson.M{"climate": bson.M{"$type": "array"}}, []string{"Tatooine", "Hoth", "Naboo"}},
+		{"exists", bson.M{"terrain": bson.M{"$exists": true}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, findNames(t, collection, tt.filter))
+		})
+	}
+}
+
+func Test_memoryCollection_FindOne(t *testing.T) {
+	collection := newMemoryCollection(t, memoryPlanet{Name: "Hoth"})
+
+	var planet memoryPlanet
+	assert.NoError(t, collection.FindOne(context.Background(), bson.M{"name": "Hoth"}).Decode(&planet))
+	assert.False(t, planet.ID.IsZero())
+
+	var byID memoryPlanet
+	assert.NoError(t, collection.FindOne(context.Background(), bson.M{"_id": planet.ID}).Decode(&byID))
+	assert.Equal(t, planet, byID)
+
+	err := collection.FindOne(context.Background(), bson.M{"name": "Dagobah"}).Decode(&planet)
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+}
+
+func Test_memoryCollection_InsertOne_with_duplicated_id(t *testing.T) {
+	id := primitive.NewObjectID()
+	collection := newMemoryCollection(t, memoryPlanet{ID: id, Name: "Hoth"})
+
+	_, err := collection.InsertOne(context.Background(), memoryPlanet{ID: id, Name: "Hoth"})
+
+	writeException, ok := err.(mongo.WriteException)
+	assert.True(t, ok)
+	assert.Equal(t, duplicateKeyErrorCode, writeException.WriteErrors[0].Code)
+}
+
+func Test_memoryCollection_UpdateOne_and_DeleteOne(t *testing.T) {
+	collection := newMemoryCollection(t, memoryPlanet{Name: "Hoth", Films: 1})
+
+	result, err := collection.UpdateOne(context.Background(), bson.M{"name": "Hoth"},
+		bson.M{"$set": bson.M{"climate": bson.A{"frozen"}}, "$inc": bson.M{"films": 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.MatchedCount)
+
+	var planet memoryPlanet
+	assert.NoError(t, collection.FindOne(context.Background(), bson.M{"name": "Hoth"}).Decode(&planet))
+	assert.Equal(t, []string{"frozen"}, planet.Climate)
+	assert.Equal(t, 3, planet.Films)
+
+	deleted, err := collection.DeleteOne(context.Background(), bson.M{"_id": planet.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted.DeletedCount)
+	assert.Equal(t, []string{}, findNames(t, collection, bson.D{{}}))
+}
+
+func Test_memoryCollection_Aggregate(t *testing.T) {
+	collection := newMemoryCollection(t,
+		memoryPlanet{Name: "Tatooine", Climate: []string{"arid"}, Films: 5},
+		memoryPlanet{Name: "Naboo", Climate: []string{"temperate"}, Films: 4},
+		memoryPlanet{Name: "Alderaan", Climate: []string{"temperate"}, Films: 2},
+	)
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$climate"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$climate"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "films", Value: bson.D{{Key: "$max", Value: "$films"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+	}
+
+	cursor, err := collection.Aggregate(context.Background(), pipeline)
+	assert.NoError(t, err)
+	var groups []struct {
+		ID    string `bson:"_id"`
+		Count int    `bson:"count"`
+		Films int    `bson:"films"`
+	}
+	assert.NoError(t, cursor.All(context.Background(), &groups))
+
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "temperate", groups[0].ID)
+	assert.Equal(t, 2, groups[0].Count)
+	assert.Equal(t, 4, groups[0].Films)
+	assert.Equal(t, "arid", groups[1].ID)
+}
+
+func Test_memoryCollection_Aggregate_with_lookup(t *testing.T) {
+	database := NewMemoryClient().Database("test")
+	planetID := primitive.NewObjectID()
+	_, err := database.Collection("planets").InsertOne(context.Background(), bson.M{"_id": planetID, "name": "Tatooine"})
+	assert.NoError(t, err)
+	_, err = database.Collection("people").InsertOne(context.Background(), bson.M{"name": "Luke", "homeworld": planetID})
+	assert.NoError(t, err)
+
+	cursor, err := database.Collection("planets").Aggregate(context.Background(), mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "people"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "homeworld"},
+			{Key: "as", Value: "residents"},
+		}}},
+		{{Key: "$addFields", Value: bson.D{{Key: "residents", Value: bson.D{{Key: "$map", Value: bson.D{
+			{Key: "input", Value: "$residents"},
+			{Key: "as", Value: "resident"},
+			{Key: "in", Value: bson.D{{Key: "name", Value: "$$resident.name"}}},
+		}}}}}}},
+	})
+	assert.NoError(t, err)
+	var planets []struct {
+		Name      string `bson:"name"`
+		Residents []struct {
+			Name string `bson:"name"`
+		} `bson:"residents"`
+	}
+	assert.NoError(t, cursor.All(context.Background(), &planets))
+
+	assert.Len(t, planets, 1)
+	assert.Len(t, planets[0].Residents, 1)
+	assert.Equal(t, "Luke", planets[0].Residents[0].Name)
+}
+
+func Test_memoryCollection_Aggregate_with_unsupported_stage(t *testing.T) {
+	collection := newMemoryCollection(t)
+
+	_, err := collection.Aggregate(context.Background(), mongo.Pipeline{{{Key: "$facet", Value: bson.D{}}}})
+
+	assert.Error(t, err)
+}
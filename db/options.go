@@ -0,0 +1,140 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+	"github.com/wallacebenevides/star-wars-api/tracing"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// clientOptions applies the credentials, tls and tuning of the config over
+// the options of the uri
+func clientOptions(cnf *config.Database) (*options.ClientOptions, error) {
+	opts := options.Client().
+		ApplyURI(cnf.Uri).
+		SetPoolMonitor(metrics.PoolMonitor()).
+		SetMonitor(tracing.CommandMonitor())
+
+	if cnf.Username != "" || cnf.AuthMechanism != "" {
+		password, err := password(cnf)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetAuth(options.Credential{
+			Username:      cnf.Username,
+			Password:      password,
+			PasswordSet:   password != "",
+			AuthSource:    cnf.AuthSource,
+			AuthMechanism: cnf.AuthMechanism,
+		})
+	}
+	if cnf.TLS.Enabled {
+		tlsConfig, err := tlsConfig(cnf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	if cnf.Pool.MaxSize > 0 {
+		opts.SetMaxPoolSize(cnf.Pool.MaxSize)
+	}
+	if cnf.Pool.MinSize > 0 {
+		opts.SetMinPoolSize(cnf.Pool.MinSize)
+	}
+	if cnf.Pool.MaxIdleTime > 0 {
+		opts.SetMaxConnIdleTime(cnf.Pool.MaxIdleTime)
+	}
+	if cnf.ConnectTimeout > 0 {
+		opts.SetConnectTimeout(cnf.ConnectTimeout)
+	}
+	if cnf.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(cnf.ServerSelectionTimeout)
+	}
+	if cnf.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(cnf.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		readPreference, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetReadPreference(readPreference)
+	}
+	if cnf.WriteConcern != "" {
+		writeConcern, err := writeConcern(cnf.WriteConcern)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetWriteConcern(writeConcern)
+	}
+	return opts, opts.Validate()
+}
+
+// password reads the password from PasswordFile, PasswordEnv or Password,
+// in that order
+func password(cnf *config.Database) (string, error) {
+	if cnf.PasswordFile != "" {
+		content, err := ioutil.ReadFile(cnf.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read the database password file: %v", err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	if cnf.PasswordEnv != "" {
+		password, ok := os.LookupEnv(cnf.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("the database password variable %s is not set", cnf.PasswordEnv)
+		}
+		return password, nil
+	}
+	return cnf.Password, nil
+}
+
+func tlsConfig(cnf config.DatabaseTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cnf.InsecureSkipVerify}
+	if cnf.CAFile != "" {
+		pem, err := ioutil.ReadFile(cnf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the database CA file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the database CA file %s", cnf.CAFile)
+		}
+	}
+	if cnf.CertFile != "" {
+		keyFile := cnf.KeyFile
+		if keyFile == "" {
+			keyFile = cnf.CertFile
+		}
+		certificate, err := tls.LoadX509KeyPair(cnf.CertFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the database client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+func writeConcern(w string) (*writeconcern.WriteConcern, error) {
+	if w == config.WRITE_CONCERN_MAJORITY {
+		return writeconcern.New(writeconcern.WMajority()), nil
+	}
+	nodes, err := strconv.Atoi(w)
+	if err != nil {
+		return nil, fmt.Errorf("invalid write concern %q", w)
+	}
+	return writeconcern.New(writeconcern.W(nodes)), nil
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(file, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// selfSignedPEM returns a certificate followed by its key
+func selfSignedPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mongodb"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})...)
+}
+
+func TestClientOptions(t *testing.T) {
+	cnf := &config.Database{
+		Uri:                    "mongodb://mongodb:27017",
+		Username:               "api",
+		Password:               "secret",
+		AuthSource:             "star_wars_db",
+		AuthMechanism:          "SCRAM-SHA-256",
+		Pool:                   config.Pool{MaxSize: 20, MinSize: 2, MaxIdleTime: time.Minute},
+		ConnectTimeout:         3 * time.Second,
+		ServerSelectionTimeout: 5 * time.Second,
+		ReadPreference:         "secondaryPreferred",
+		WriteConcern:           config.WRITE_CONCERN_MAJORITY,
+	}
+
+	opts, err := clientOptions(cnf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "api", opts.Auth.Username)
+	assert.Equal(t, "secret", opts.Auth.Password)
+	assert.Equal(t, "star_wars_db", opts.Auth.AuthSource)
+	assert.Equal(t, "SCRAM-SHA-256", opts.Auth.AuthMechanism)
+	assert.Equal(t, uint64(20), *opts.MaxPoolSize)
+	assert.Equal(t, uint64(2), *opts.MinPoolSize)
+	assert.Equal(t, time.Minute, *opts.MaxConnIdleTime)
+	assert.Equal(t, 3*time.Second, *opts.ConnectTimeout)
+	assert.Equal(t, 5*time.Second, *opts.ServerSelectionTimeout)
+	assert.Equal(t, readpref.SecondaryPreferredMode, opts.ReadPreference.Mode())
+	assert.Equal(t, "majority", opts.WriteConcern.GetW())
+	assert.Nil(t, opts.TLSConfig)
+}
+
+func TestClientOptions_uri_options(t *testing.T) {
+	uri := "mongodb://mongodb:27017/?readPreference=nearest&connectTimeoutMS=3000&serverSelectionTimeoutMS=5000"
+
+	opts, err := clientOptions(&config.Database{Uri: uri})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, *opts.ConnectTimeout)
+	assert.Equal(t, 5*time.Second, *opts.ServerSelectionTimeout)
+	assert.Equal(t, readpref.NearestMode, opts.ReadPreference.Mode())
+
+	opts, err = clientOptions(&config.Database{Uri: uri, ReadPreference: "secondary", ConnectTimeout: time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, *opts.ConnectTimeout, "the config wins when set")
+	assert.Equal(t, readpref.SecondaryMode, opts.ReadPreference.Mode())
+}
+
+func TestClientOptions_without_credentials(t *testing.T) {
+	opts, err := clientOptions(&config.Database{Uri: "mongodb://mongodb:27017", WriteConcern: "2"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, opts.Auth)
+	assert.Equal(t, 2, opts.WriteConcern.GetW())
+}
+
+func TestPassword(t *testing.T) {
+	os.Setenv("MOCKED_MONGO_PASSWORD", "from-env")
+	defer os.Unsetenv("MOCKED_MONGO_PASSWORD")
+	file := writeFile(t, "password", []byte("from-file\n"))
+
+	tests := []struct {
+		name    string
+		cnf     config.Database
+		want    string
+		wantErr bool
+	}{
+		{"inline", config.Database{Password: "inline"}, "inline", false},
+		{"env", config.Database{Password: "inline", PasswordEnv: "MOCKED_MONGO_PASSWORD"}, "from-env", false},
+		{"file", config.Database{PasswordEnv: "MOCKED_MONGO_PASSWORD", PasswordFile: file}, "from-file", false},
+		{"missing env", config.Database{PasswordEnv: "MOCKED_MISSING_PASSWORD"}, "", true},
+		{"missing file", config.Database{PasswordFile: file + ".missing"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := password(&tt.cnf)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClientOptions_tls(t *testing.T) {
+	pem := selfSignedPEM(t)
+	ca := writeFile(t, "ca.pem", pem)
+	cert := writeFile(t, "client.pem", pem)
+
+	opts, err := clientOptions(&config.Database{
+		Uri: "mongodb://mongodb:27017",
+		TLS: config.DatabaseTLS{Enabled: true, CAFile: ca, CertFile: cert},
+	})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, opts.TLSConfig.RootCAs)
+	assert.Len(t, opts.TLSConfig.Certificates, 1)
+
+	_, err = clientOptions(&config.Database{
+		Uri: "mongodb://mongodb:27017",
+		TLS: config.DatabaseTLS{Enabled: true, CAFile: writeFile(t, "empty.pem", nil)},
+	})
+	assert.Error(t, err)
+}
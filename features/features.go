@@ -0,0 +1,50 @@
+package features
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// ALL enables every feature, it applies to the requests that didn't go
+// through Flags.Middleware
+var ALL = config.Features{ExpandResidents: true, LegacyFormat: true}
+
+// Flags holds the features of the config, Set swaps them while serving
+type Flags struct {
+	value atomic.Value
+}
+
+func New(cnf config.Features) *Flags {
+	f := &Flags{}
+	f.Set(cnf)
+	return f
+}
+
+// Set replaces the features, the requests already running keep theirs
+func (f *Flags) Set(cnf config.Features) {
+	f.value.Store(cnf)
+}
+
+// Middleware gives every request the current features, read with FromContext
+func (f *Flags) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithFeatures(r.Context(), f.value.Load().(config.Features))))
+	})
+}
+
+type featuresKey struct{}
+
+func WithFeatures(ctx context.Context, cnf config.Features) context.Context {
+	return context.WithValue(ctx, featuresKey{}, cnf)
+}
+
+// FromContext returns the features of a request, ALL when it has none
+func FromContext(ctx context.Context) config.Features {
+	if cnf, ok := ctx.Value(featuresKey{}).(config.Features); ok {
+		return cnf
+	}
+	return ALL
+}
@@ -0,0 +1,29 @@
+package features
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+func TestFlags_Middleware(t *testing.T) {
+	flags := New(config.Features{ExpandResidents: true})
+	var got config.Features
+	handler := flags.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/planets", nil))
+	assert.Equal(t, config.Features{ExpandResidents: true}, got)
+
+	flags.Set(config.Features{LegacyFormat: true})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/planets", nil))
+	assert.Equal(t, config.Features{LegacyFormat: true}, got)
+}
+
+func TestFromContext_without_features(t *testing.T) {
+	assert.Equal(t, ALL, FromContext(httptest.NewRequest(http.MethodGet, "/api/planets", nil).Context()))
+}
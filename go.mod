@@ -1,21 +1,28 @@
 module github.com/wallacebenevides/star-wars-api
 
-go 1.13
+go 1.16
 
 require (
 	github.com/DataDog/zstd v1.4.4 // indirect
+	github.com/fsnotify/fsnotify v1.4.7
+	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/golang/snappy v0.0.1 // indirect
-	github.com/google/go-cmp v0.4.0 // indirect
 	github.com/gorilla/mux v1.7.3
-	github.com/pkg/errors v0.9.0 // indirect
-	github.com/sirupsen/logrus v1.4.2
+	github.com/mitchellh/mapstructure v1.1.2
+	github.com/prometheus/client_golang v1.11.1
+	github.com/prometheus/client_model v0.2.0
+	github.com/sirupsen/logrus v1.6.0
 	github.com/spf13/viper v1.6.1
-	github.com/stretchr/testify v1.2.2
+	github.com/stretchr/testify v1.7.0
 	github.com/tidwall/pretty v1.0.0 // indirect
 	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c // indirect
 	github.com/xdg/stringprep v1.0.0 // indirect
+	go.etcd.io/bbolt v1.3.6
 	go.mongodb.org/mongo-driver v1.2.1
-	golang.org/x/crypto v0.0.0-20200109152110-61a87790db17 // indirect
-	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e // indirect
-	golang.org/x/text v0.3.2 // indirect
+	go.opentelemetry.io/otel v1.3.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.3.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.3.0
+	go.opentelemetry.io/otel/sdk v1.3.0
+	go.opentelemetry.io/otel/trace v1.3.0
+	gopkg.in/yaml.v2 v2.3.0
 )
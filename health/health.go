@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	STATUS_OK   = "ok"
+	STATUS_FAIL = "fail"
+)
+
+const (
+	SHUTTING_DOWN_ERROR_MESSAGE = "shutting down"
+)
+
+const (
+	// CHECK_TIMEOUT bounds every readiness check, so a hanging dependency fails the probe
+	CHECK_TIMEOUT = 2 * time.Second
+)
+
+// Check reports whether a dependency can serve requests
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the registered dependency checks for the readiness probe
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Register adds a readiness check, checks run concurrently in each probe
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Shutdown makes the readiness probe fail, so no new traffic is routed to a stopping server
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shuttingDown = true
+}
+
+// Ready runs every check and reports each one with its latency
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck{}, c.checks...)
+	shuttingDown := c.shuttingDown
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, CHECK_TIMEOUT)
+	defer cancel()
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check namedCheck) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	if shuttingDown {
+		results = append(results, CheckResult{
+			Name:   "shutdown",
+			Status: STATUS_FAIL,
+			Error:  SHUTTING_DOWN_ERROR_MESSAGE,
+		})
+	}
+	report := Report{Status: STATUS_OK, Checks: results}
+	for _, result := range results {
+		if result.Status != STATUS_OK {
+			report.Status = STATUS_FAIL
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, check namedCheck) CheckResult {
+	start := time.Now()
+	err := check.check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	result := CheckResult{
+		Name:      check.name,
+		Status:    STATUS_OK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("timed out after " + CHECK_TIMEOUT.String())
+		}
+		result.Status = STATUS_FAIL
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Liveness answers as long as the process can serve http
+func (c *Checker) Liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, Report{Status: STATUS_OK, Checks: []CheckResult{}})
+	}
+}
+
+// Readiness answers 503 when any check fails or the server is shutting down
+func (c *Checker) Readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		code := http.StatusOK
+		if report.Status != STATUS_OK {
+			code = http.StatusServiceUnavailable
+		}
+		respond(w, code, report)
+	}
+}
+
+func respond(w http.ResponseWriter, code int, report Report) {
+	response, _ := json.Marshal(report)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	w.Write(response)
+}
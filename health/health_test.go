@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+)
+
+func probe(t *testing.T, handler http.HandlerFunc) (int, Report) {
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	return rr.Code, report
+}
+
+func TestChecker_Liveness(t *testing.T) {
+	checker := NewChecker()
+	checker.Register("database", func(ctx context.Context) error { return errors.New("down") })
+
+	code, report := probe(t, checker.Liveness())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, STATUS_OK, report.Status)
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	client := &mocks.ClientHelper{}
+	client.On("Ping", mock.Anything).Return(nil)
+	checker := NewChecker()
+	checker.Register("database", client.Ping)
+
+	code, report := probe(t, checker.Readiness())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, STATUS_OK, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, STATUS_OK, report.Checks[0].Status)
+	assert.True(t, report.Checks[0].LatencyMs >= 0)
+	client.AssertExpectations(t)
+}
+
+func TestChecker_Readiness_with_failing_check(t *testing.T) {
+	checker := NewChecker()
+	checker.Register("database", func(ctx context.Context) error { return nil })
+	checker.Register("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	code, report := probe(t, checker.Readiness())
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, STATUS_FAIL, report.Status)
+	assert.Equal(t, STATUS_OK, report.Checks[0].Status)
+	assert.Equal(t, STATUS_FAIL, report.Checks[1].Status)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+}
+
+func TestChecker_Readiness_during_shutdown(t *testing.T) {
+	checker := NewChecker()
+	checker.Register("database", func(ctx context.Context) error { return nil })
+	checker.Shutdown()
+
+	code, report := probe(t, checker.Readiness())
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, STATUS_FAIL, report.Status)
+	assert.Equal(t, CheckResult{Name: "shutdown", Status: STATUS_FAIL, Error: SHUTTING_DOWN_ERROR_MESSAGE}, report.Checks[1])
+}
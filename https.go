@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/certs"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// newServer builds the http.Server of the config, with https and http/2 when
+// tls is enabled. The certificate is reloaded when its files change until the
+// server drains.
+func newServer(cnf config.Server, handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              ":" + cnf.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: cnf.ReadHeaderTimeout,
+		ReadTimeout:       cnf.ReadTimeout,
+		WriteTimeout:      cnf.WriteTimeout,
+		IdleTimeout:       cnf.IdleTimeout,
+		MaxHeaderBytes:    cnf.MaxHeaderBytes,
+	}
+	if !cnf.TLS.Enabled {
+		return srv, nil
+	}
+	reloader, err := certs.NewReloader(cnf.TLS.CertFile, cnf.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = tlsConfig(cnf.TLS, reloader.GetCertificate)
+
+	stop := make(chan struct{})
+	onDrain(func() { close(stop) })
+	if err := reloader.Watch(stop); err != nil {
+		log.WithError(err).Warn("The certificate won't be reloaded when it changes")
+	}
+	return srv, nil
+}
+
+func tlsConfig(cnf config.ServerTLS, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if version, ok := config.TLS_VERSIONS[cnf.MinVersion]; ok {
+		tlsConfig.MinVersion = version
+	}
+	for _, name := range cnf.CipherSuites {
+		if id, ok := config.CipherSuite(name); ok {
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+	return tlsConfig
+}
+
+// serveRedirect listens for plain http on the redirect port of the config and
+// sends every request to https, it stops with the other shutdown hooks
+func serveRedirect(cnf config.Server) error {
+	srv := &http.Server{
+		Addr:              ":" + cnf.TLS.RedirectPort,
+		Handler:           redirectHandler(cnf.Port),
+		ReadHeaderTimeout: cnf.ReadHeaderTimeout,
+		ReadTimeout:       cnf.ReadTimeout,
+		WriteTimeout:      cnf.WriteTimeout,
+		IdleTimeout:       cnf.IdleTimeout,
+		MaxHeaderBytes:    cnf.MaxHeaderBytes,
+	}
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("The https redirect stopped")
+		}
+	}()
+	onShutdown("redirect", srv.Shutdown)
+	log.Info("Redirecting http from port ", cnf.TLS.RedirectPort, " to https")
+	return nil
+}
+
+// redirectHandler answers 308 so the redirected requests keep their method and body
+func redirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its key
+func writeCertificate(t *testing.T) (string, string, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	certificate, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return certFile, keyFile, pool
+}
+
+func Test_newServer(t *testing.T) {
+	srv, err := newServer(config.Server{
+		Port: "8080", ReadHeaderTimeout: time.Second, WriteTimeout: time.Minute, MaxHeaderBytes: 4096,
+	}, http.NotFoundHandler())
+
+	assert.NoError(t, err)
+	assert.Equal(t, ":8080", srv.Addr)
+	assert.Equal(t, time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, time.Minute, srv.WriteTimeout)
+	assert.Equal(t, 4096, srv.MaxHeaderBytes)
+	assert.Nil(t, srv.TLSConfig)
+}
+
+func Test_newServer_serves_http2_over_tls(t *testing.T) {
+	certFile, keyFile, pool := writeCertificate(t)
+	srv, err := newServer(config.Server{TLS: config.ServerTLS{
+		Enabled: true, CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3",
+	}}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), srv.TLSConfig.MinVersion)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(srv, listener, time.Second, stop)
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool},
+		ForceAttemptHTTP2: true,
+	}}
+
+	resp, err := client.Get("https://" + listener.Addr().String())
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "HTTP/2.0", string(body))
+	}
+
+	stop <- syscall.SIGTERM
+	assert.NoError(t, <-done)
+}
+
+func Test_newServer_missing_certificate(t *testing.T) {
+	_, err := newServer(config.Server{TLS: config.ServerTLS{
+		Enabled: true, CertFile: "missing.crt", KeyFile: "missing.key",
+	}}, http.NotFoundHandler())
+
+	assert.Error(t, err)
+}
+
+func Test_tlsConfig_cipher_suites(t *testing.T) {
+	got := tlsConfig(config.ServerTLS{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}, nil)
+
+	assert.Equal(t, uint16(tls.VersionTLS12), got.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, got.CipherSuites)
+}
+
+func Test_redirectHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort string
+		target    string
+		want      string
+	}{
+		{"default port", "443", "http://api.example.com/api/planets?name=Hoth", "https://api.example.com/api/planets?name=Hoth"},
+		{"other port", "8443", "http://api.example.com:8080/api/planets", "https://api.example.com:8443/api/planets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			redirectHandler(tt.httpsPort).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			assert.Equal(t, http.StatusPermanentRedirect, rr.Code)
+			assert.Equal(t, tt.want, rr.Header().Get("Location"))
+		})
+	}
+}
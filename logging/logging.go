@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+type contextKey struct{}
+
+// WithEntry returns a copy of ctx carrying the request-scoped log entry
+func WithEntry(ctx context.Context, entry *log.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// FromContext returns the log entry of the request, with its request id and
+// route, or the standard logger when ctx doesn't come from a request
+func FromContext(ctx context.Context) *log.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*log.Entry); ok {
+		return entry
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+// Setup applies the level and the format of the config to the standard logger
+func Setup(cnf config.Logging) error {
+	level := log.InfoLevel
+	if cnf.Level != "" {
+		var err error
+		if level, err = log.ParseLevel(cnf.Level); err != nil {
+			return err
+		}
+	}
+	switch cnf.Format {
+	case "", config.LOG_FORMAT_TEXT:
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	case config.LOG_FORMAT_JSON:
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q", cnf.Format)
+	}
+	log.SetLevel(level)
+	return nil
+}
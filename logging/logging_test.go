@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, log.StandardLogger(), FromContext(context.Background()).Logger)
+
+	entry := log.WithField("request_id", "mocked-id")
+	ctx := WithEntry(context.Background(), entry)
+	assert.Equal(t, entry, FromContext(ctx))
+}
+
+func TestSetup(t *testing.T) {
+	logger := log.StandardLogger()
+	level, formatter := logger.GetLevel(), logger.Formatter
+	defer func() {
+		logger.SetLevel(level)
+		logger.SetFormatter(formatter)
+	}()
+
+	assert.NoError(t, Setup(config.Logging{Level: "debug", Format: config.LOG_FORMAT_JSON}))
+	assert.Equal(t, log.DebugLevel, logger.GetLevel())
+	assert.IsType(t, &log.JSONFormatter{}, logger.Formatter)
+
+	assert.NoError(t, Setup(config.Logging{}))
+	assert.Equal(t, log.InfoLevel, logger.GetLevel())
+	assert.IsType(t, &log.TextFormatter{}, logger.Formatter)
+
+	assert.Error(t, Setup(config.Logging{Level: "loud"}))
+	assert.Error(t, Setup(config.Logging{Format: "xml"}))
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	NAMESPACE = "star_wars_api"
+)
+
+// Registry holds every metric of the api along with the go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_requests_total",
+		Help:      "Number of http requests by mux route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the http requests by mux route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	DAODuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "dao_operation_duration_seconds",
+		Help:      "Duration of the DAO operations by DAO, method and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"dao", "operation", "outcome"})
+
+	MongoPoolConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Name:      "mongo_pool_connections",
+		Help:      "Connections of the mongo pool by server address and state (open or in_use).",
+	}, []string{"address", "state"})
+
+	MongoPoolCheckoutFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "mongo_pool_checkout_failures_total",
+		Help:      "Number of times a connection couldn't be checked out of the mongo pool.",
+	}, []string{"address"})
+
+	CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Name:      "circuit_breaker_state",
+		Help:      "State of the circuit breakers by name: 0 closed, 1 half-open, 2 open.",
+	}, []string{"name"})
+
+	CircuitBreakerRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "circuit_breaker_rejections_total",
+		Help:      "Number of calls failed fast by the circuit breakers, by name.",
+	}, []string{"name"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Number of requests answered 429, by rate limit group.",
+	}, []string{"group"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		DAODuration,
+		MongoPoolConnections,
+		MongoPoolCheckoutFailures,
+		CircuitBreakerState,
+		CircuitBreakerRejections,
+		RateLimitRejections,
+	)
+}
+
+// Handler serves the registry in the prometheus text exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
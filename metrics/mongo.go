@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const (
+	POOL_STATE_OPEN   = "open"
+	POOL_STATE_IN_USE = "in_use"
+)
+
+// PoolMonitor keeps the mongo pool gauges up to date from the driver pool events
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				MongoPoolConnections.WithLabelValues(e.Address, POOL_STATE_OPEN).Inc()
+			case event.ConnectionClosed:
+				MongoPoolConnections.WithLabelValues(e.Address, POOL_STATE_OPEN).Dec()
+			case event.GetSucceeded:
+				MongoPoolConnections.WithLabelValues(e.Address, POOL_STATE_IN_USE).Inc()
+			case event.ConnectionReturned:
+				MongoPoolConnections.WithLabelValues(e.Address, POOL_STATE_IN_USE).Dec()
+			case event.GetFailed:
+				MongoPoolCheckoutFailures.WithLabelValues(e.Address).Inc()
+			}
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestPoolMonitor(t *testing.T) {
+	monitor := PoolMonitor()
+	for _, eventType := range []string{
+		event.ConnectionCreated,
+		event.ConnectionCreated,
+		event.GetSucceeded,
+		event.GetSucceeded,
+		event.ConnectionReturned,
+		event.ConnectionClosed,
+		event.GetFailed,
+	} {
+		monitor.Event(&event.PoolEvent{Type: eventType, Address: "mongodb:27017"})
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(MongoPoolConnections.WithLabelValues("mongodb:27017", POOL_STATE_OPEN)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(MongoPoolConnections.WithLabelValues("mongodb:27017", POOL_STATE_IN_USE)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(MongoPoolCheckoutFailures.WithLabelValues("mongodb:27017")))
+}
+
+func TestHandler_exposes_runtime_metrics(t *testing.T) {
+	count, err := testutil.GatherAndCount(Registry, "go_goroutines", "process_cpu_seconds_total")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/logging"
+)
+
+// Authenticate sets the principal of each request from the first
+// authenticator recognizing its credentials, the requests without valid
+// credentials are answered 401. The scopes are checked by the routes.
+func Authenticate(authenticators ...auth.Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if err != nil {
+					if err.Error() != auth.UNAUTHORIZED_ERROR_MESSAGE {
+						logging.FromContext(r.Context()).WithError(err).Error("There was an error authenticating the request")
+					}
+					auth.RespondWithError(w, err)
+					return
+				}
+				if principal != nil {
+					next.ServeHTTP(w, r.WithContext(withPrincipal(r, *principal)))
+					return
+				}
+			}
+			auth.RespondWithError(w, errors.New(auth.UNAUTHORIZED_ERROR_MESSAGE))
+		})
+	}
+}
+
+// Anonymous gives every request the auth.ANONYMOUS principal, it replaces
+// Authenticate while the authentication is disabled
+func Anonymous(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), auth.ANONYMOUS)))
+	})
+}
+
+// withPrincipal also logs the principal with the rest of the request
+func withPrincipal(r *http.Request, principal auth.Principal) context.Context {
+	ctx := logging.WithEntry(r.Context(), logging.FromContext(r.Context()).WithField("principal", principal.Name))
+	return auth.WithPrincipal(ctx, principal)
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/auth"
+)
+
+type authenticatorFunc func(r *http.Request) (*auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(r *http.Request) (*auth.Principal, error) {
+	return f(r)
+}
+
+func TestAuthenticate(t *testing.T) {
+	none := authenticatorFunc(func(r *http.Request) (*auth.Principal, error) { return nil, nil })
+	reader := authenticatorFunc(func(r *http.Request) (*auth.Principal, error) {
+		return &auth.Principal{Name: "reader", Scopes: []string{auth.SCOPE_PLANETS_READ}}, nil
+	})
+	invalid := authenticatorFunc(func(r *http.Request) (*auth.Principal, error) {
+		return nil, errors.New(auth.UNAUTHORIZED_ERROR_MESSAGE)
+	})
+	failing := authenticatorFunc(func(r *http.Request) (*auth.Principal, error) {
+		return nil, errors.New("server selection error")
+	})
+	tests := []struct {
+		name           string
+		authenticators []auth.Authenticator
+		want           int
+		wantPrincipal  string
+	}{
+		{"first recognizing the credentials", []auth.Authenticator{none, reader}, http.StatusOK, "reader"},
+		{"no credentials", []auth.Authenticator{none}, http.StatusUnauthorized, ""},
+		{"invalid credentials", []auth.Authenticator{invalid, reader}, http.StatusUnauthorized, ""},
+		{"error", []auth.Authenticator{failing}, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Authenticate(tt.authenticators...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ := auth.FromContext(r.Context())
+				got = principal.Name
+			}))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/planets", nil))
+
+			assert.Equal(t, tt.want, rr.Code)
+			assert.Equal(t, tt.wantPrincipal, got)
+			if tt.want == http.StatusUnauthorized {
+				assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAnonymous(t *testing.T) {
+	var got auth.Principal
+	Anonymous(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.FromContext(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/planets", nil))
+
+	assert.True(t, got.HasScope(auth.SCOPE_PLANETS_WRITE))
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// RETRY_AFTER is sent to the clients of an unavailable server
+	RETRY_AFTER = 5 * time.Second
+)
+
+// Available answers 503 with a Retry-After header and the message while
+// available returns false, e.g. while the database can't be reached
+func Available(available func() bool, message string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if available() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(RETRY_AFTER.Seconds())))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": message})
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/logging"
+)
+
+// CORS_REJECTED_ERROR_MESSAGE answers the preflight requests of an origin, a
+// method or headers that aren't allowed
+const CORS_REJECTED_ERROR_MESSAGE = "CORS request not allowed"
+
+// CORS sets the CORS headers of the allowed origins and answers their
+// preflight requests, Set swaps the config while serving
+type CORS struct {
+	cnf atomic.Value
+}
+
+func NewCORS(cnf config.CORS) *CORS {
+	c := &CORS{}
+	c.Set(cnf)
+	return c
+}
+
+func (c *CORS) Set(cnf config.CORS) {
+	c.cnf.Store(&cnf)
+}
+
+// Handler wraps the whole router: mux answers 405 to the OPTIONS requests
+// before running the middlewares, so the preflight requests are answered
+// here for every route of the router registered for the requested method.
+// The requests of the other origins get no CORS headers, the browsers then
+// keep their responses from the scripts.
+func (c *CORS) Handler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cnf := c.cnf.Load().(*config.CORS)
+		if !cnf.Enabled {
+			router.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && origin != "" && requestedMethod != "" {
+			c.preflight(w, r, router, cnf, origin, requestedMethod)
+			return
+		}
+		if origin != "" && allowedOrigin(cnf, origin) {
+			setAllowOrigin(w, cnf, origin)
+			if len(cnf.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(cnf.ExposedHeaders, ", "))
+			}
+		}
+		router.ServeHTTP(w, r)
+	})
+}
+
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, router *mux.Router, cnf *config.CORS, origin, requestedMethod string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	var match mux.RouteMatch
+	if !router.Match(r, &match) && match.MatchErr != mux.ErrMethodMismatch {
+		// no route has the path
+		router.ServeHTTP(w, r)
+		return
+	}
+	requestedHeaders := splitHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+	if !allowedOrigin(cnf, origin) || !allowedMethod(cnf, requestedMethod) ||
+		!routeAllows(router, r, requestedMethod) || !allowedHeaders(cnf, requestedHeaders) {
+		logging.FromContext(r.Context()).WithFields(map[string]interface{}{
+			"origin": origin, "method": requestedMethod, "headers": requestedHeaders, "path": r.URL.Path,
+		}).Debug("Rejected a CORS preflight request")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": CORS_REJECTED_ERROR_MESSAGE})
+		return
+	}
+	setAllowOrigin(w, cnf, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(routeMethods(router, r, cnf.AllowedMethods), ", "))
+	if len(requestedHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+	}
+	if cnf.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cnf.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setAllowOrigin(w http.ResponseWriter, cnf *config.CORS, origin string) {
+	if contains(cnf.AllowedOrigins, "*") && !cnf.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if cnf.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowedOrigin matches the origin with the allowed ones, "https://*.example.com"
+// matches the subdomains of example.com with the same scheme and port
+func allowedOrigin(cnf *config.CORS, origin string) bool {
+	for _, allowed := range cnf.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if i := strings.Index(allowed, "://*."); i >= 0 {
+			u, err := url.Parse(origin)
+			if err != nil || !strings.EqualFold(u.Scheme, allowed[:i]) {
+				continue
+			}
+			suffix := allowed[i+len("://*"):]
+			if host := u.Host; len(host) > len(suffix) && strings.HasSuffix(strings.ToLower(host), strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allowedMethod(cnf *config.CORS, method string) bool {
+	for _, allowed := range cnf.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedHeaders(cnf *config.CORS, headers []string) bool {
+	for _, header := range headers {
+		allowed := false
+		for _, h := range cnf.AllowedHeaders {
+			if h == "*" || strings.EqualFold(h, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// routeAllows tells whether a route of the router has the path of the
+// request for the method
+func routeAllows(router *mux.Router, r *http.Request, method string) bool {
+	probe := *r
+	probe.Method = strings.ToUpper(method)
+	var match mux.RouteMatch
+	return router.Match(&probe, &match)
+}
+
+// routeMethods are the allowed methods the routes of the path are registered for
+func routeMethods(router *mux.Router, r *http.Request, methods []string) []string {
+	allowed := []string{}
+	for _, method := range methods {
+		if routeAllows(router, r, method) {
+			allowed = append(allowed, strings.ToUpper(method))
+		}
+	}
+	return allowed
+}
+
+func splitHeaderList(value string) []string {
+	headers := []string{}
+	for _, header := range strings.Split(value, ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
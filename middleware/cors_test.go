@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+func corsConfig() config.CORS {
+	return config.CORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"X-Request-ID", "Retry-After"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+func newCORSRouter() *mux.Router {
+	r := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/planets", ok).Methods(http.MethodGet, http.MethodPost)
+	api.HandleFunc("/planets/{id}", ok).Methods(http.MethodGet, http.MethodDelete)
+	return r
+}
+
+func corsRequest(h http.Handler, method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func preflight(method, headers string) map[string]string {
+	return map[string]string{"Access-Control-Request-Method": method, "Access-Control-Request-Headers": headers}
+}
+
+func TestCORS_preflight(t *testing.T) {
+	h := NewCORS(corsConfig()).Handler(newCORSRouter())
+
+	rr := corsRequest(h, http.MethodOptions, "/api/planets/1", "https://app.example.com", preflight("DELETE", "x-api-key, content-type"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, DELETE", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "x-api-key, content-type", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rr.Header().Values("Vary"))
+
+	rr = corsRequest(h, http.MethodOptions, "/api/planets", "https://admin.eu.example.org", preflight("POST", ""))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://admin.eu.example.org", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORS_preflight_rejected(t *testing.T) {
+	h := NewCORS(corsConfig()).Handler(newCORSRouter())
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+	}{
+		{"origin", "https://evil.example.com", "GET", ""},
+		{"wildcard origin of another scheme", "http://app.example.org", "GET", ""},
+		{"wildcard origin without subdomain", "https://example.org", "GET", ""},
+		{"method not allowed", "https://app.example.com", "PATCH", ""},
+		{"method not registered", "https://app.example.com", "PUT", ""},
+		{"header", "https://app.example.com", "GET", "X-Debug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := corsRequest(h, http.MethodOptions, "/api/planets", tt.origin, preflight(tt.method, tt.headers))
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+			assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.JSONEq(t, `{"error":"CORS request not allowed"}`, rr.Body.String())
+		})
+	}
+
+	rr := corsRequest(h, http.MethodOptions, "/api/films", "https://app.example.com", preflight("GET", ""))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCORS_request(t *testing.T) {
+	h := NewCORS(corsConfig()).Handler(newCORSRouter())
+
+	rr := corsRequest(h, http.MethodGet, "/api/planets", "https://app.example.com", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Request-ID, Retry-After", rr.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+	rr = corsRequest(h, http.MethodGet, "/api/planets", "https://evil.example.com", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+	rr = corsRequest(h, http.MethodGet, "/api/planets", "", nil)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+	// an OPTIONS request without Access-Control-Request-Method isn't a preflight
+	rr = corsRequest(h, http.MethodOptions, "/api/planets", "https://app.example.com", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestCORS_credentials(t *testing.T) {
+	cnf := corsConfig()
+	cnf.AllowCredentials = true
+	h := NewCORS(cnf).Handler(newCORSRouter())
+
+	rr := corsRequest(h, http.MethodGet, "/api/planets", "https://app.example.com", nil)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+
+	rr = corsRequest(h, http.MethodOptions, "/api/planets", "https://app.example.com", preflight("POST", "Authorization"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_any_origin(t *testing.T) {
+	cnf := corsConfig()
+	cnf.AllowedOrigins = []string{"*"}
+	cnf.AllowedHeaders = []string{"*"}
+	h := NewCORS(cnf).Handler(newCORSRouter())
+
+	rr := corsRequest(h, http.MethodOptions, "/api/planets", "https://anywhere.test", preflight("GET", "X-Debug"))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Debug", rr.Header().Get("Access-Control-Allow-Headers"))
+}
+
+func TestCORS_Set(t *testing.T) {
+	cors := NewCORS(config.CORS{})
+	h := cors.Handler(newCORSRouter())
+
+	rr := corsRequest(h, http.MethodGet, "/api/planets", "https://app.example.com", nil)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Vary"))
+	rr = corsRequest(h, http.MethodOptions, "/api/planets", "https://app.example.com", preflight("GET", ""))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	cors.Set(corsConfig())
+	rr = corsRequest(h, http.MethodOptions, "/api/planets", "https://app.example.com", preflight("GET", ""))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	REQUEST_ID_HEADER = "X-Request-ID"
+	// MAX_REQUEST_ID_LENGTH bounds the ids accepted from the callers
+	MAX_REQUEST_ID_LENGTH = 128
+)
+
+// Logging propagates the X-Request-ID of the caller, or assigns one, puts a
+// log entry tagged with it in the request context and writes one access log
+// line per request once the handler returned
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(REQUEST_ID_HEADER)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(REQUEST_ID_HEADER, id)
+
+		entry := log.WithFields(log.Fields{
+			"request_id": id,
+			"method":     r.Method,
+			"route":      routeTemplate(r),
+		})
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			entry = entry.WithField("trace_id", span.TraceID().String())
+		}
+
+		recorder := newResponseRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(logging.WithEntry(r.Context(), entry)))
+
+		entry.WithFields(log.Fields{
+			"path":       r.URL.Path,
+			"remote":     r.RemoteAddr,
+			"status":     recorder.status,
+			"bytes":      recorder.bytes,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		}).Info("request completed")
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > MAX_REQUEST_ID_LENGTH {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
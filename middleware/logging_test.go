@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/logging"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger := log.StandardLogger()
+	out, formatter := logger.Out, logger.Formatter
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&log.JSONFormatter{})
+	t.Cleanup(func() {
+		logger.SetOutput(out)
+		logger.SetFormatter(formatter)
+	})
+	return &buf
+}
+
+func TestLogging(t *testing.T) {
+	buf := captureLogs(t)
+	r := mux.NewRouter()
+	r.Use(Logging)
+	r.HandleFunc("/api/planets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("finding the planet")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"document not found"}`))
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/planets/1", nil))
+
+	id := rr.Header().Get(REQUEST_ID_HEADER)
+	assert.Len(t, id, 32)
+	decoder := json.NewDecoder(buf)
+	var handlerLine, accessLine map[string]interface{}
+	assert.NoError(t, decoder.Decode(&handlerLine))
+	assert.NoError(t, decoder.Decode(&accessLine))
+	assert.Equal(t, id, handlerLine["request_id"])
+	assert.Equal(t, "finding the planet", handlerLine["msg"])
+	assert.Equal(t, id, accessLine["request_id"])
+	assert.Equal(t, http.MethodGet, accessLine["method"])
+	assert.Equal(t, "/api/planets/{id}", accessLine["route"])
+	assert.Equal(t, float64(http.StatusNotFound), accessLine["status"])
+	assert.Equal(t, float64(len(`{"error":"document not found"}`)), accessLine["bytes"])
+	assert.Contains(t, accessLine, "latency_ms")
+}
+
+func TestLogging_propagates_request_id(t *testing.T) {
+	buf := captureLogs(t)
+	handler := Logging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"caller id", "mocked-request-id", true},
+		{"invalid id", "bad id\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/api/planets", nil)
+			req.Header.Set(REQUEST_ID_HEADER, tt.header)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(REQUEST_ID_HEADER)
+			assert.Equal(t, tt.keep, id == tt.header)
+			assert.NotEmpty(t, id)
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+			assert.Equal(t, id, line["request_id"])
+			assert.Equal(t, UNKNOWN_ROUTE, line["route"])
+		})
+	}
+}
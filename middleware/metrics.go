@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+)
+
+const (
+	UNKNOWN_ROUTE = "unknown"
+)
+
+// Metrics counts the requests and observes their latency, labelled by the
+// route template so "/api/planets/{id}" is a single series for every id
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := newResponseRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		route := routeTemplate(r)
+		status := strconv.Itoa(recorder.status)
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return UNKNOWN_ROUTE
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(Metrics)
+	r.HandleFunc("/api/planets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	found := metrics.HTTPRequests.WithLabelValues("/api/planets/{id}", http.MethodGet, "200")
+	notFound := metrics.HTTPRequests.WithLabelValues("/api/planets/{id}", http.MethodGet, "404")
+	before, beforeNotFound := testutil.ToFloat64(found), testutil.ToFloat64(notFound)
+
+	for _, path := range []string{"/api/planets/1", "/api/planets/2", "/api/planets/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, before+2, testutil.ToFloat64(found))
+	assert.Equal(t, beforeNotFound+1, testutil.ToFloat64(notFound))
+	var histogram dto.Metric
+	metrics.HTTPRequestDuration.WithLabelValues("/api/planets/{id}", http.MethodGet, "200").(prometheus.Metric).Write(&histogram)
+	assert.True(t, histogram.GetHistogram().GetSampleCount() >= 2)
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+	"github.com/wallacebenevides/star-wars-api/ratelimit"
+)
+
+const (
+	RATE_LIMITED_ERROR_MESSAGE = "Too Many Requests"
+	// DEFAULT_RATE_LIMIT_GROUP holds the routes out of the configured groups
+	DEFAULT_RATE_LIMIT_GROUP = "default"
+	// UNAUTHENTICATED_RATE_LIMIT_GROUP holds the requests failing the authentication
+	UNAUTHENTICATED_RATE_LIMIT_GROUP = "unauthenticated"
+	// EVICT_INTERVAL is how often the idle buckets are evicted
+	EVICT_INTERVAL = time.Minute
+)
+
+// RateLimit limits the requests of each client by route group, Set swaps
+// the limits while serving
+type RateLimit struct {
+	settings atomic.Value
+	limiter  *ratelimit.Limiter
+}
+
+type rateLimitSettings struct {
+	cnf             config.RateLimit
+	defaultLimit    ratelimit.Limit
+	unauthenticated ratelimit.Limit
+	groups          []rateLimitGroup
+	trusted         []*net.IPNet
+}
+
+type rateLimitGroup struct {
+	name    string
+	paths   map[string]bool
+	methods map[string]bool
+	limit   ratelimit.Limit
+}
+
+func NewRateLimit(cnf config.RateLimit) *RateLimit {
+	rl := &RateLimit{limiter: ratelimit.New()}
+	rl.Set(cnf)
+	return rl
+}
+
+// Set replaces the limits, the buckets start over when they changed
+func (rl *RateLimit) Set(cnf config.RateLimit) {
+	if current, ok := rl.settings.Load().(*rateLimitSettings); ok {
+		if reflect.DeepEqual(current.cnf, cnf) {
+			return
+		}
+		rl.limiter.Reset()
+	}
+	settings := &rateLimitSettings{cnf: cnf, defaultLimit: limit(cnf.Default), unauthenticated: limit(cnf.Unauthenticated)}
+	for _, group := range cnf.Groups {
+		g := rateLimitGroup{name: group.Name, paths: map[string]bool{}, methods: map[string]bool{}, limit: limit(group.Limit)}
+		for _, path := range group.Paths {
+			g.paths[path] = true
+		}
+		for _, method := range group.Methods {
+			g.methods[strings.ToUpper(method)] = true
+		}
+		settings.groups = append(settings.groups, g)
+	}
+	for _, proxy := range cnf.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			settings.trusted = append(settings.trusted, network)
+		}
+	}
+	rl.settings.Store(settings)
+}
+
+func limit(l config.Limit) ratelimit.Limit {
+	burst := l.Burst
+	if burst == 0 {
+		burst = l.Requests
+	}
+	return ratelimit.Limit{Rate: float64(l.Requests) / l.Period.Seconds(), Burst: burst}
+}
+
+// Middleware takes a token from the bucket of the client for the group of
+// the route, and answers 429 when it is empty. The clients are the
+// principals, or the IP of the anonymous requests, so it must run after the
+// authentication, and Unauthenticated before it. Every response tells the
+// state of the bucket in the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers.
+func (rl *RateLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := rl.settings.Load().(*rateLimitSettings)
+		if !settings.cnf.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		group, limit := settings.group(r)
+		client := "ip:" + ClientIP(r, settings.trusted)
+		if principal, ok := auth.FromContext(r.Context()); ok && principal.ID != "" {
+			client = principal.ID
+		}
+		result := rl.limiter.Allow(group+"|"+client, limit)
+
+		if !respond(w, r, group, result) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Unauthenticated limits by IP the requests failing the authentication, it
+// must run before it: the requests of an IP out of tokens are answered 429
+// without checking their credentials, and each 401 takes a token. The
+// requests with valid credentials take none, the clients behind the same IP
+// don't share a limit.
+func (rl *RateLimit) Unauthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := rl.settings.Load().(*rateLimitSettings)
+		if !settings.cnf.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := UNAUTHENTICATED_RATE_LIMIT_GROUP + "|ip:" + ClientIP(r, settings.trusted)
+		if !respond(w, r, UNAUTHENTICATED_RATE_LIMIT_GROUP, rl.limiter.Peek(key, settings.unauthenticated)) {
+			return
+		}
+		next.ServeHTTP(&unauthorizedWriter{ResponseWriter: w, onUnauthorized: func() {
+			setRateLimitHeaders(w, rl.limiter.Allow(key, settings.unauthenticated))
+		}}, r)
+	})
+}
+
+// respond sets the RateLimit headers of the result, and answers 429 when
+// the request isn't allowed
+func respond(w http.ResponseWriter, r *http.Request, group string, result ratelimit.Result) bool {
+	setRateLimitHeaders(w, result)
+	if result.Allowed {
+		return true
+	}
+	metrics.RateLimitRejections.WithLabelValues(group).Inc()
+	logging.FromContext(r.Context()).WithField("group", group).Debug("Rate limited")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(map[string]string{"error": RATE_LIMITED_ERROR_MESSAGE})
+	return false
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+// unauthorizedWriter calls onUnauthorized before writing a 401 header
+type unauthorizedWriter struct {
+	http.ResponseWriter
+	onUnauthorized func()
+}
+
+func (uw *unauthorizedWriter) WriteHeader(status int) {
+	if status == http.StatusUnauthorized {
+		uw.onUnauthorized()
+	}
+	uw.ResponseWriter.WriteHeader(status)
+}
+
+// group returns the first group with the route template and the method of
+// the request, or the default one
+func (s *rateLimitSettings) group(r *http.Request) (string, ratelimit.Limit) {
+	template := routeTemplate(r)
+	for _, group := range s.groups {
+		if group.paths[template] && (len(group.methods) == 0 || group.methods[r.Method]) {
+			return group.name, group.limit
+		}
+	}
+	return DEFAULT_RATE_LIMIT_GROUP, s.defaultLimit
+}
+
+// Run evicts the buckets idle for longer than the idle timeout until stop is closed
+func (rl *RateLimit) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(EVICT_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			settings := rl.settings.Load().(*rateLimitSettings)
+			rl.limiter.Evict(settings.cnf.IdleTimeout)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ClientIP returns the IP of the client of a request. The X-Forwarded-For
+// header is only read when the request comes from a trusted proxy, from
+// the last hop back to the first IP that isn't a trusted proxy.
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !isTrusted(ip, trusted) {
+		return ip
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+	return ip
+}
+
+func isTrusted(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
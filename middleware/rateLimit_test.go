@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+func rateLimitConfig() config.RateLimit {
+	return config.RateLimit{
+		Enabled: true,
+		Default: config.Limit{Requests: 10, Period: time.Minute},
+		Groups: []config.RateLimitGroup{{
+			Name: "planets-list", Paths: []string{"/api/planets"}, Methods: []string{"get"},
+			Limit: config.Limit{Requests: 1, Period: time.Minute, Burst: 2},
+		}},
+		Unauthenticated: config.Limit{Requests: 2, Period: time.Minute},
+		TrustedProxies:  []string{"10.0.0.0/8"},
+		IdleTimeout:     time.Minute,
+	}
+}
+
+func newRateLimitRouter(rl *RateLimit) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(rl.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("/api/planets", ok).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/api/films", ok).Methods(http.MethodGet)
+	return r
+}
+
+func rateLimited(r http.Handler, method, path, remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), *principal))
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRateLimit(t *testing.T) {
+	r := newRateLimitRouter(NewRateLimit(rateLimitConfig()))
+
+	rr := rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, http.StatusOK, rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil).Code)
+
+	rr = rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.JSONEq(t, `{"error":"Too Many Requests"}`, rr.Body.String())
+
+	assert.Equal(t, http.StatusOK, rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.2:1234", nil).Code, "other client")
+	assert.Equal(t, http.StatusOK, rateLimited(r, http.MethodGet, "/api/films", "192.0.2.1:1234", nil).Code, "other group")
+	rr = rateLimited(r, http.MethodPost, "/api/planets", "192.0.2.1:1234", nil)
+	assert.Equal(t, http.StatusOK, rr.Code, "other method")
+	assert.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimit_principals(t *testing.T) {
+	r := newRateLimitRouter(NewRateLimit(rateLimitConfig()))
+	ci := &auth.Principal{ID: "api_key:1", Name: "ci"}
+
+	rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", ci)
+	rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.2:1234", ci)
+
+	assert.Equal(t, http.StatusTooManyRequests, rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.3:1234", ci).Code,
+		"the bucket follows the key across IPs")
+	assert.Equal(t, http.StatusOK, rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", &auth.ANONYMOUS).Code,
+		"anonymous requests are limited by IP")
+}
+
+func TestRateLimit_Unauthenticated(t *testing.T) {
+	rl := NewRateLimit(rateLimitConfig())
+	lookups := 0
+	r := mux.NewRouter()
+	r.Use(rl.Unauthenticated)
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lookups++
+			if r.Header.Get(auth.API_KEY_HEADER) != "good" {
+				auth.RespondWithError(w, errors.New(auth.UNAUTHORIZED_ERROR_MESSAGE))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), auth.Principal{ID: "api_key:1"})))
+		})
+	})
+	r.Use(rl.Middleware)
+	r.HandleFunc("/api/films", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/films", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(auth.API_KEY_HEADER, key)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request("guess")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+	rr = request("good")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "9", rr.Header().Get("RateLimit-Remaining"), "the valid credentials have their own bucket")
+	assert.Equal(t, http.StatusUnauthorized, request("guess").Code)
+
+	rr = request("guess")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	assert.Equal(t, 3, lookups, "the credentials of the limited requests aren't checked")
+	assert.Equal(t, http.StatusTooManyRequests, request("good").Code, "the IP is limited until its bucket refills")
+}
+
+func TestRateLimit_Set(t *testing.T) {
+	rl := NewRateLimit(rateLimitConfig())
+	r := newRateLimitRouter(rl)
+	rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil)
+	rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil)
+
+	rl.Set(rateLimitConfig())
+	assert.Equal(t, http.StatusTooManyRequests, rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil).Code,
+		"the buckets are kept when the limits didn't change")
+
+	cnf := rateLimitConfig()
+	cnf.Groups[0].Burst = 5
+	rl.Set(cnf)
+	assert.Equal(t, "4", rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil).Header().Get("RateLimit-Remaining"))
+
+	cnf.Enabled = false
+	rl.Set(cnf)
+	rr := rateLimited(r, http.MethodGet, "/api/planets", "192.0.2.1:1234", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+}
+
+func TestClientIP(t *testing.T) {
+	_, private, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{private}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"untrusted proxy", "192.0.2.1:1234", []string{"198.51.100.7"}, "192.0.2.1"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"spoofed hops before the client", "10.0.0.1:1234", []string{"203.0.113.9, 198.51.100.7, 10.0.0.2"}, "198.51.100.7"},
+		{"several headers", "10.0.0.1:1234", []string{"203.0.113.9", "198.51.100.7"}, "198.51.100.7"},
+		{"only trusted hops", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"invalid hop", "10.0.0.1:1234", []string{"unknown"}, "10.0.0.1"},
+		{"ipv6", "[2001:db8::1]:1234", nil, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/planets", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			assert.Equal(t, tt.want, ClientIP(req, trusted))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// Timeouts holds the request deadlines by route template, Set swaps them
+// while serving
+type Timeouts struct {
+	table atomic.Value
+}
+
+type timeoutTable struct {
+	defaultTimeout time.Duration
+	routes         map[string]time.Duration
+}
+
+func NewTimeouts(cnf config.Timeouts) *Timeouts {
+	t := &Timeouts{}
+	t.Set(cnf)
+	return t
+}
+
+// Set replaces the deadlines, the requests already running keep theirs
+func (t *Timeouts) Set(cnf config.Timeouts) {
+	routes := map[string]time.Duration{}
+	for _, route := range cnf.Routes {
+		routes[route.Path] = route.Timeout
+	}
+	t.table.Store(&timeoutTable{defaultTimeout: cnf.Default, routes: routes})
+}
+
+// Middleware gives every request a deadline, the one configured for its route
+// template or the default one. Handlers pass the request context down to the
+// DAOs, so the queries stop when the deadline is exceeded or the client goes away.
+func (t *Timeouts) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		table := t.table.Load().(*timeoutTable)
+		timeout := table.defaultTimeout
+		if routeTimeout, ok := table.routes[routeTemplate(r)]; ok {
+			timeout = routeTimeout
+		}
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+func TestTimeouts_Middleware(t *testing.T) {
+	cnf := config.Timeouts{
+		Default: time.Second,
+		Routes:  []config.RouteTimeout{{Path: "/api/planets/stats", Timeout: time.Minute}},
+	}
+	tests := []struct {
+		name string
+		cnf  config.Timeouts
+		path string
+		want time.Duration
+	}{
+		{"default timeout", cnf, "/api/planets", time.Second},
+		{"route timeout", cnf, "/api/planets/stats", time.Minute},
+		{"no timeout", config.Timeouts{}, "/api/planets", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Duration
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if deadline, ok := r.Context().Deadline(); ok {
+					got = time.Until(deadline).Round(time.Second)
+				}
+			}
+			r := mux.NewRouter()
+			api := r.PathPrefix("/api").Subrouter()
+			api.Use(NewTimeouts(tt.cnf).Middleware)
+			api.HandleFunc("/planets", handler)
+			api.HandleFunc("/planets/stats", handler)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTimeouts_Middleware_keeps_route_variables(t *testing.T) {
+	var id string
+	r := mux.NewRouter()
+	r.Use(NewTimeouts(config.Timeouts{Default: time.Second}).Middleware)
+	r.HandleFunc("/planets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id = mux.Vars(r)["id"]
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/planets/42", nil))
+
+	assert.Equal(t, "42", id)
+}
+
+func TestTimeouts_Set(t *testing.T) {
+	var got time.Duration
+	timeouts := NewTimeouts(config.Timeouts{Default: time.Second})
+	r := mux.NewRouter()
+	r.Use(timeouts.Middleware)
+	r.HandleFunc("/api/planets", func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		got = time.Until(deadline).Round(time.Second)
+	})
+
+	timeouts.Set(config.Timeouts{Default: time.Minute})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/planets", nil))
+
+	assert.Equal(t, time.Minute, got)
+}
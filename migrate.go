@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/migrations"
+)
+
+// migrate applies the pending migrations, waiting for other replicas holding the lock
+func migrate(ctx context.Context, database db.DatabaseHelper) error {
+	applied, err := migrations.NewMigrator(database, migrations.All()).Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.Info("Applied ", len(applied), " migrations")
+	return nil
+}
+
+// runMigrateCommand handles "migrate up", "migrate down [steps]" and "migrate status"
+func runMigrateCommand(database db.DatabaseHelper, args []string) {
+	migrator := migrations.NewMigrator(database, migrations.All())
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "up":
+		if err := migrate(context.Background(), database); err != nil {
+			log.Fatal(err.Error())
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(context.Background(), steps)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Info("Reverted migrations ", reverted)
+	case "status":
+		status, err := migrator.Status(context.Background())
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(status)
+	default:
+		log.Fatalf("unknown migrate command %q, use up, down [steps] or status", action)
+	}
+}
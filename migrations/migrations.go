@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+	"sort"
+
+	"github.com/wallacebenevides/star-wars-api/db"
+)
+
+// Migration evolves the documents of the database from Version-1 to Version (Up) and back (Down)
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db db.DatabaseHelper) error
+	Down        func(ctx context.Context, db db.DatabaseHelper) error
+}
+
+// All returns the migrations of the application ordered by version
+func All() []Migration {
+	migrations := []Migration{
+		planetListsMigration,
+		planetFilmLinksMigration,
+		apiKeyHashIndexMigration,
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
@@ -0,0 +1,275 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	COLLECTION = "schema_migrations"
+	LOCK_ID    = "lock"
+)
+
+const (
+	LOCKED_ERROR_MESSAGE    = "migrations are locked by another process"
+	LOCK_LOST_ERROR_MESSAGE = "the migration lock expired before the migrations were done"
+)
+
+const (
+	duplicateKeyErrorCode = 11000
+	defaultLockTTL        = 5 * time.Minute
+	lockRetryInterval     = time.Second
+)
+
+// AppliedMigration is the record of a migration in the schema_migrations collection
+type AppliedMigration struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"appliedAt"`
+}
+
+type migrationLock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// MigrationStatus tells whether a migration was applied
+type MigrationStatus struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator applies and reverts migrations, holding a lock in the schema_migrations
+// collection so concurrent replicas don't race
+type Migrator struct {
+	db         db.DatabaseHelper
+	migrations []Migration
+	owner      string
+	lockTTL    time.Duration
+}
+
+func NewMigrator(db db.DatabaseHelper, migrations []Migration) *Migrator {
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		lockTTL:    defaultLockTTL,
+	}
+}
+
+// Up applies every pending migration in version order and returns the applied versions
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	applied := []int{}
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			logger := log.WithField("version", migration.Version)
+			logger.Info("Applying migration ", migration.Description)
+			if err := migration.Up(ctx, m.db); err != nil {
+				logger.Error("There was an error applying the migration::", err.Error())
+				return err
+			}
+			record := AppliedMigration{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now().UTC()}
+			if _, err := m.collection().InsertOne(ctx, record); err != nil {
+				return err
+			}
+			applied = append(applied, migration.Version)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns the reverted versions
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	reverted := []int{}
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			logger := log.WithField("version", migration.Version)
+			logger.Info("Reverting migration ", migration.Description)
+			if migration.Down == nil {
+				return fmt.Errorf("migration %d can't be reverted", migration.Version)
+			}
+			if err := migration.Down(ctx, m.db); err != nil {
+				logger.Error("There was an error reverting the migration::", err.Error())
+				return err
+			}
+			if _, err := m.collection().DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration.Version)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration with the date it was applied, if any
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := []MigrationStatus{}
+	for _, migration := range m.migrations {
+		s := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := done[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]AppliedMigration, error) {
+	cursor, err := m.collection().Find(ctx, bson.M{"applied_at": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var records []AppliedMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := map[int]AppliedMigration{}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// withLock runs fn holding the migration lock, which is extended while fn
+// runs. The context of fn is cancelled when the lock expired, e.g. when the
+// database didn't answer for its ttl, as another process may have taken it
+// over, and the run fails.
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop, lost := make(chan struct{}), make(chan struct{})
+	go m.heartbeat(ctx, stop, lost, cancel)
+	err := fn(ctx)
+	close(stop)
+	select {
+	case <-lost:
+		return errors.New(LOCK_LOST_ERROR_MESSAGE)
+	default:
+		return err
+	}
+}
+
+// heartbeat extends the lock every third of its ttl until stop is closed,
+// and closes lost and cancels the migrations once it expired
+func (m *Migrator) heartbeat(ctx context.Context, stop <-chan struct{}, lost chan<- struct{}, cancel func()) {
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+	expiresAt := time.Now().Add(m.lockTTL)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		held, err := m.extendLock(ctx)
+		if err == nil && held {
+			expiresAt = time.Now().Add(m.lockTTL)
+			continue
+		}
+		if err != nil && time.Now().Before(expiresAt) {
+			log.Warn("There was an error extending the migration lock::", err.Error())
+			continue
+		}
+		log.Error("Lost the migration lock, cancelling the migrations")
+		close(lost)
+		cancel()
+		return
+	}
+}
+
+// extendLock pushes back the expiry of the lock, telling whether it is still held
+func (m *Migrator) extendLock(ctx context.Context) (bool, error) {
+	filter := bson.M{"_id": LOCK_ID, "owner": m.owner}
+	update := bson.M{"$set": bson.M{"expires_at": time.Now().UTC().Add(m.lockTTL)}}
+	result, err := m.collection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// lock waits until the migration lock is acquired, taking over expired locks
+func (m *Migrator) lock(ctx context.Context) error {
+	for {
+		now := time.Now().UTC()
+		lock := migrationLock{ID: LOCK_ID, Owner: m.owner, ExpiresAt: now.Add(m.lockTTL)}
+		_, err := m.collection().InsertOne(ctx, lock)
+		if err == nil {
+			return nil
+		}
+		if !isDuplicateKeyError(err) {
+			return err
+		}
+		expired := bson.M{"_id": LOCK_ID, "expires_at": bson.M{"$lt": now}}
+		if result, err := m.collection().DeleteOne(ctx, expired); err == nil && result.DeletedCount > 0 {
+			log.Warn("Took over an expired migration lock")
+			continue
+		}
+		log.Debug("Waiting for the migration lock")
+		select {
+		case <-ctx.Done():
+			return errors.New(LOCKED_ERROR_MESSAGE)
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func (m *Migrator) unlock() {
+	// the lock must be released even if the migration context was cancelled
+	if _, err := m.collection().DeleteOne(context.Background(), bson.M{"_id": LOCK_ID, "owner": m.owner}); err != nil {
+		log.Error("There was an error releasing the migration lock::", err.Error())
+	}
+}
+
+func (m *Migrator) collection() db.CollectionHelper {
+	return m.db.Collection(COLLECTION)
+}
+
+func isDuplicateKeyError(err error) bool {
+	if we, ok := err.(mongo.WriteException); ok {
+		for _, writeError := range we.WriteErrors {
+			if writeError.Code == duplicateKeyErrorCode {
+				return true
+			}
+		}
+	}
+	return false
+}
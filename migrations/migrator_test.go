@@ -0,0 +1,185 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func recordingMigration(version int, calls *[]string) Migration {
+	return Migration{
+		Version:     version,
+		Description: "mocked-migration",
+		Up: func(ctx context.Context, db db.DatabaseHelper) error {
+			*calls = append(*calls, "up")
+			return nil
+		},
+		Down: func(ctx context.Context, db db.DatabaseHelper) error {
+			*calls = append(*calls, "down")
+			return nil
+		},
+	}
+}
+
+func mockAppliedMigrations(collectionHelper *mocks.CollectionHelper, records []AppliedMigration) {
+	cursor := &mocks.CursorHelper{}
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{"applied_at": bson.M{"$exists": true}}).
+		Once().
+		Return(cursor, nil)
+	cursor.On("Close", mock.Anything).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]AppliedMigration)
+			*arg = records
+		}).
+		Return(nil)
+}
+
+func TestMigrator_Up(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	var calls []string
+	migrator := NewMigrator(dbHelper, []Migration{recordingMigration(1, &calls), recordingMigration(2, &calls)})
+
+	dbHelper.
+		On("Collection", "schema_migrations").
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("InsertOne", context.Background(), mock.AnythingOfType("migrations.migrationLock")).
+		Once().
+		Return(LOCK_ID, nil)
+	mockAppliedMigrations(collectionHelper, []AppliedMigration{{Version: 1}})
+	collectionHelper.
+		On("InsertOne", mock.Anything, mock.AnythingOfType("migrations.AppliedMigration")).
+		Once().
+		Return(2, nil)
+	collectionHelper.
+		On("DeleteOne", context.Background(), bson.M{"_id": LOCK_ID, "owner": migrator.owner}).
+		Once().
+		Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+
+	applied, err := migrator.Up(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, applied)
+	assert.Equal(t, []string{"up"}, calls)
+	collectionHelper.AssertExpectations(t)
+}
+
+func TestMigrator_Up_with_lock_held(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbHelper.
+		On("Collection", "schema_migrations").
+		Return(collectionHelper)
+
+	duplicateKey := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+	collectionHelper.
+		On("InsertOne", mock.Anything, mock.AnythingOfType("migrations.migrationLock")).
+		Return(nil, duplicateKey)
+	collectionHelper.
+		On("DeleteOne", mock.Anything, mock.Anything).
+		Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+
+	var calls []string
+	migrator := NewMigrator(dbHelper, []Migration{recordingMigration(1, &calls)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	applied, err := migrator.Up(ctx)
+
+	assert.EqualError(t, err, LOCKED_ERROR_MESSAGE)
+	assert.Empty(t, applied)
+	assert.Empty(t, calls)
+}
+
+func TestMigrator_Down(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	var calls []string
+	migrator := NewMigrator(dbHelper, []Migration{recordingMigration(1, &calls), recordingMigration(2, &calls)})
+
+	dbHelper.
+		On("Collection", "schema_migrations").
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("InsertOne", context.Background(), mock.AnythingOfType("migrations.migrationLock")).
+		Once().
+		Return(LOCK_ID, nil)
+	mockAppliedMigrations(collectionHelper, []AppliedMigration{{Version: 1}, {Version: 2}})
+	collectionHelper.
+		On("DeleteOne", mock.Anything, bson.M{"_id": 2}).
+		Once().
+		Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+	collectionHelper.
+		On("DeleteOne", context.Background(), bson.M{"_id": LOCK_ID, "owner": migrator.owner}).
+		Once().
+		Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+
+	reverted, err := migrator.Down(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, reverted)
+	assert.Equal(t, []string{"down"}, calls)
+}
+
+func TestMigrator_Up_with_lock_lost(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	blocking := Migration{
+		Version:     1,
+		Description: "mocked-migration",
+		Up: func(ctx context.Context, db db.DatabaseHelper) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	migrator := NewMigrator(dbHelper, []Migration{blocking})
+	migrator.lockTTL = 30 * time.Millisecond
+
+	dbHelper.
+		On("Collection", "schema_migrations").
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("InsertOne", context.Background(), mock.AnythingOfType("migrations.migrationLock")).
+		Once().
+		Return(LOCK_ID, nil)
+	mockAppliedMigrations(collectionHelper, nil)
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": LOCK_ID, "owner": migrator.owner}, mock.Anything).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": LOCK_ID, "owner": migrator.owner}, mock.Anything).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+	collectionHelper.
+		On("DeleteOne", context.Background(), bson.M{"_id": LOCK_ID, "owner": migrator.owner}).
+		Once().
+		Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+
+	applied, err := migrator.Up(context.Background())
+
+	assert.EqualError(t, err, LOCK_LOST_ERROR_MESSAGE)
+	assert.Empty(t, applied)
+	collectionHelper.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var planetListsMigration = Migration{
+	Version:     1,
+	Description: "store planet climate and terrain as lists",
+	Up:          planetListsUp,
+	Down:        planetListsDown,
+}
+
+// planetListsUp rewrites the legacy comma separated climate and terrain strings as lists
+func planetListsUp(ctx context.Context, db db.DatabaseHelper) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"climate": bson.M{"$type": "string"}},
+		bson.M{"terrain": bson.M{"$type": "string"}},
+	}}
+	return updatePlanets(ctx, db, filter, func(planet models.Planet) bson.M {
+		return bson.M{"climate": planet.Climate, "terrain": planet.Terrain}
+	})
+}
+
+// planetListsDown joins the climate and terrain lists back into comma separated strings
+func planetListsDown(ctx context.Context, db db.DatabaseHelper) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"climate": bson.M{"$type": "array"}},
+		bson.M{"terrain": bson.M{"$type": "array"}},
+	}}
+	return updatePlanets(ctx, db, filter, func(planet models.Planet) bson.M {
+		return bson.M{"climate": planet.Climate.String(), "terrain": planet.Terrain.String()}
+	})
+}
+
+// updatePlanets sets the fields returned for each planet matching the
+// filter, the planets without fields are left as they are
+func updatePlanets(ctx context.Context, db db.DatabaseHelper, filter bson.M, fields func(models.Planet) bson.M) error {
+	collection := db.Collection(dao.COLLECTION)
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	var planets []models.Planet
+	if err := cursor.All(ctx, &planets); err != nil {
+		return err
+	}
+	migrated := 0
+	for _, planet := range planets {
+		set := fields(planet)
+		if set == nil {
+			continue
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": planet.ID}, bson.M{"$set": set}); err != nil {
+			log.WithField("id", planet.ID.Hex()).Error("There was an error migrating the planet::", err.Error())
+			return err
+		}
+		migrated++
+	}
+	log.Info("Migrated ", migrated, " planets")
+	return nil
+}
@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_planetListsUp(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	id, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+
+	dbHelper.
+		On("Collection", "planets").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Find", context.Background(), mock.Anything).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.Planet)
+			*arg = []models.Planet{{ID: id, Climate: models.ParseStringList("Frozen"), Terrain: models.ParseStringList("tundra, ice caves")}}
+		}).
+		Return(nil)
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), bson.M{"_id": id},
+			bson.M{"$set": bson.M{"climate": models.StringList{"frozen"}, "terrain": models.StringList{"tundra", "ice caves"}}}).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	err := planetListsUp(context.Background(), dbHelper)
+
+	assert.NoError(t, err)
+	collectionHelper.AssertExpectations(t)
+}
+
+func Test_planetListsDown(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursor := &mocks.CursorHelper{}
+
+	id, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+
+	dbHelper.
+		On("Collection", "planets").
+		Once().
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("Find", context.Background(), mock.Anything).
+		Once().
+		Return(cursor, nil)
+
+	cursor.On("Close", context.Background()).Return(nil)
+	cursor.On("All", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(1).(*[]models.Planet)
+			*arg = []models.Planet{{ID: id, Climate: models.StringList{"frozen"}, Terrain: models.StringList{"tundra", "ice caves"}}}
+		}).
+		Return(nil)
+
+	collectionHelper.
+		On("UpdateOne", context.Background(), bson.M{"_id": id},
+			bson.M{"$set": bson.M{"climate": "frozen", "terrain": "tundra, ice caves"}}).
+		Once().
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	err := planetListsDown(context.Background(), dbHelper)
+
+	assert.NoError(t, err)
+	collectionHelper.AssertExpectations(t)
+}
@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"github.com/wallacebenevides/star-wars-api/seed"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LEGACY_FILMS_FIELD keeps the films count a planet had before its films were linked
+const LEGACY_FILMS_FIELD = "legacy_films"
+
+var planetFilmLinksMigration = Migration{
+	Version:     2,
+	Description: "link the planets to their films and derive the films count from the links",
+	Up:          planetFilmLinksUp,
+	Down:        planetFilmLinksDown,
+}
+
+// planetFilmLinksUp links the legacy planets to the films of their episodes
+// in the seed datasets, matched by planet name, and sets the films count of
+// the linked planets to the number of links. The planets whose episodes
+// can't all be resolved to a film of the database keep their count, and the
+// replaced counts are kept in LEGACY_FILMS_FIELD for Down.
+func planetFilmLinksUp(ctx context.Context, db db.DatabaseHelper) error {
+	episodes, err := seedEpisodes()
+	if err != nil {
+		return err
+	}
+	films, err := dao.NewFilmsDao(db).FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	filmIDs := map[int]primitive.ObjectID{}
+	for _, film := range films {
+		filmIDs[film.EpisodeID] = film.ID
+	}
+	unresolved := 0
+	err = updatePlanets(ctx, db, bson.M{LEGACY_FILMS_FIELD: bson.M{"$exists": false}}, func(planet models.Planet) bson.M {
+		links := planet.FilmIDs
+		if links == nil {
+			var ok bool
+			if links, ok = resolveFilms(episodes[strings.ToLower(planet.Name)], filmIDs); !ok {
+				unresolved++
+				return nil
+			}
+		}
+		if len(links) == planet.Films && planet.FilmIDs != nil {
+			return nil
+		}
+		return bson.M{"film_ids": links, "films": len(links), LEGACY_FILMS_FIELD: planet.Films}
+	})
+	if unresolved > 0 {
+		log.Warn("Kept the films count of ", unresolved, " planets whose films couldn't be resolved")
+	}
+	return err
+}
+
+// planetFilmLinksDown restores the films counts replaced by Up, the links
+// are kept as the earlier schema ignores them
+func planetFilmLinksDown(ctx context.Context, db db.DatabaseHelper) error {
+	collection := db.Collection(dao.COLLECTION)
+	cursor, err := collection.Find(ctx, bson.M{LEGACY_FILMS_FIELD: bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	var planets []bson.M
+	if err := cursor.All(ctx, &planets); err != nil {
+		return err
+	}
+	for _, planet := range planets {
+		update := bson.M{"$set": bson.M{"films": planet[LEGACY_FILMS_FIELD]}, "$unset": bson.M{LEGACY_FILMS_FIELD: ""}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": planet["_id"]}, update); err != nil {
+			log.WithField("id", planet["_id"]).Error("There was an error migrating the planet::", err.Error())
+			return err
+		}
+	}
+	log.Info("Migrated ", len(planets), " planets")
+	return nil
+}
+
+// seedEpisodes returns the episodes of the planets of every seed profile by
+// lowercase name
+func seedEpisodes() (map[string][]int, error) {
+	episodes := map[string][]int{}
+	for _, profile := range seed.Profiles() {
+		dataset, err := seed.Load(profile)
+		if err != nil {
+			return nil, err
+		}
+		for _, planet := range dataset.Planets {
+			name := strings.ToLower(planet.Name)
+			if _, ok := episodes[name]; !ok {
+				episodes[name] = planet.Episodes
+			}
+		}
+	}
+	return episodes, nil
+}
+
+// resolveFilms returns the ids of the films of the episodes, which must all
+// be known and at least one
+func resolveFilms(episodes []int, filmIDs map[int]primitive.ObjectID) ([]primitive.ObjectID, bool) {
+	if len(episodes) == 0 {
+		return nil, false
+	}
+	links := []primitive.ObjectID{}
+	for _, episode := range episodes {
+		filmID, ok := filmIDs[episode]
+		if !ok {
+			return nil, false
+		}
+		links = append(links, filmID)
+	}
+	return links, true
+}
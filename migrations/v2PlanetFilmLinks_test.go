@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func planetsByName(t *testing.T, database db.DatabaseHelper) map[string]models.Planet {
+	planets, err := dao.NewPlanetsDao(database).FindAll(context.Background())
+	assert.NoError(t, err)
+	byName := map[string]models.Planet{}
+	for _, planet := range planets {
+		byName[planet.Name] = planet
+	}
+	return byName
+}
+
+func Test_planetFilmLinks(t *testing.T) {
+	ctx := context.Background()
+	database := db.NewMemoryClient().Database("test")
+	films := map[int]primitive.ObjectID{}
+	for _, episode := range []int{1, 2, 3, 4, 5, 6} {
+		films[episode] = primitive.NewObjectID()
+		database.Collection(dao.FILMS_COLLECTION).InsertOne(ctx, bson.M{"_id": films[episode], "episode_id": episode})
+	}
+	planets := database.Collection(dao.COLLECTION)
+	planets.InsertOne(ctx, bson.M{"_id": primitive.NewObjectID(), "name": "Tatooine", "films": 5})
+	planets.InsertOne(ctx, bson.M{"_id": primitive.NewObjectID(), "name": "Jakku", "films": 1})
+	planets.InsertOne(ctx, bson.M{"_id": primitive.NewObjectID(), "name": "Ilum", "films": 1})
+	planets.InsertOne(ctx, bson.M{"_id": primitive.NewObjectID(), "name": "Hoth", "films": 3, "film_ids": bson.A{films[5]}})
+
+	assert.NoError(t, planetFilmLinksUp(ctx, database))
+
+	byName := planetsByName(t, database)
+	assert.Equal(t, 5, byName["Tatooine"].Films, "the count of the resolved links")
+	assert.Equal(t, []primitive.ObjectID{films[1], films[2], films[3], films[4], films[6]}, byName["Tatooine"].FilmIDs)
+	assert.Equal(t, 1, byName["Jakku"].Films, "the film of Jakku is not in the database")
+	assert.Nil(t, byName["Jakku"].FilmIDs)
+	assert.Equal(t, 1, byName["Ilum"].Films, "Ilum is not in the seeds")
+	assert.Equal(t, 1, byName["Hoth"].Films)
+
+	planetsDao := dao.NewPlanetsDao(database)
+	assert.NoError(t, planetsDao.RemoveFilm(ctx, byName["Tatooine"].ID.Hex(), films[6].Hex()))
+	tatooine, err := planetsDao.FindByID(ctx, byName["Tatooine"].ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, tatooine.Films)
+
+	assert.NoError(t, planetFilmLinksUp(ctx, database), "the migration may run again")
+	assert.NoError(t, planetFilmLinksDown(ctx, database))
+
+	byName = planetsByName(t, database)
+	assert.Equal(t, 5, byName["Tatooine"].Films, "the legacy counts are restored")
+	assert.Equal(t, 1, byName["Jakku"].Films)
+	assert.Equal(t, 1, byName["Ilum"].Films)
+	assert.Equal(t, 3, byName["Hoth"].Films)
+}
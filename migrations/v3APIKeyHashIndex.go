@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// API_KEY_HASH_INDEX is the default mongo name of the index on the api key hashes
+const API_KEY_HASH_INDEX = "hash_1"
+
+var apiKeyHashIndexMigration = Migration{
+	Version:     3,
+	Description: "index the api key hashes",
+	Up:          apiKeyHashIndexUp,
+	Down:        apiKeyHashIndexDown,
+}
+
+// apiKeyHashIndexUp creates a unique index on the api key hashes, every
+// authenticated request looks its key up by hash
+func apiKeyHashIndexUp(ctx context.Context, db db.DatabaseHelper) error {
+	indexes, ok := apiKeysIndexes(db)
+	if !ok {
+		return nil
+	}
+	name, err := indexes.CreateIndex(ctx, bson.D{{Key: "hash", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+	log.WithField("index", name).Info("Created the api key hash index")
+	return nil
+}
+
+func apiKeyHashIndexDown(ctx context.Context, db db.DatabaseHelper) error {
+	indexes, ok := apiKeysIndexes(db)
+	if !ok {
+		return nil
+	}
+	return indexes.DropIndex(ctx, API_KEY_HASH_INDEX)
+}
+
+// apiKeysIndexes returns the indexes of the api keys collection, the
+// in-process drivers have none
+func apiKeysIndexes(database db.DatabaseHelper) (db.IndexHelper, bool) {
+	indexes, ok := database.Collection(dao.API_KEYS_COLLECTION).(db.IndexHelper)
+	return indexes, ok
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// indexedCollection is a collection of the mongo driver, with indexes
+type indexedCollection struct {
+	*mocks.CollectionHelper
+	*mocks.IndexHelper
+}
+
+func Test_apiKeyHashIndexUp(t *testing.T) {
+
+	dbHelper := &mocks.DatabaseHelper{}
+	indexHelper := &mocks.IndexHelper{}
+
+	dbHelper.
+		On("Collection", "api_keys").
+		Return(indexedCollection{&mocks.CollectionHelper{}, indexHelper})
+
+	indexHelper.
+		On("CreateIndex", context.Background(), bson.D{{Key: "hash", Value: 1}}, true).
+		Once().
+		Return(API_KEY_HASH_INDEX, nil)
+
+	indexHelper.
+		On("DropIndex", context.Background(), API_KEY_HASH_INDEX).
+		Once().
+		Return(nil)
+
+	assert.NoError(t, apiKeyHashIndexUp(context.Background(), dbHelper))
+	assert.NoError(t, apiKeyHashIndexDown(context.Background(), dbHelper))
+	indexHelper.AssertExpectations(t)
+}
+
+func Test_apiKeyHashIndexUp_without_indexes(t *testing.T) {
+	assert.NoError(t, apiKeyHashIndexUp(context.Background(), db.NewMemoryClient().Database("test")))
+}
@@ -2,6 +2,7 @@
 
 package mocks
 
+import context "context"
 import db "github.com/wallacebenevides/star-wars-api/db"
 import mock "github.com/stretchr/testify/mock"
 
@@ -25,3 +26,31 @@ func (_m *ClientHelper) Database(_a0 string) db.DatabaseHelper {
 
 	return r0
 }
+
+// Disconnect provides a mock function with given fields: ctx
+func (_m *ClientHelper) Disconnect(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *ClientHelper) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -12,6 +12,29 @@ type CollectionHelper struct {
 	mock.Mock
 }
 
+// Aggregate provides a mock function with given fields: ctx, pipeline
+func (_m *CollectionHelper) Aggregate(ctx context.Context, pipeline interface{}) (db.CursorHelper, error) {
+	ret := _m.Called(ctx, pipeline)
+
+	var r0 db.CursorHelper
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}) db.CursorHelper); ok {
+		r0 = rf(ctx, pipeline)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(db.CursorHelper)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}) error); ok {
+		r1 = rf(ctx, pipeline)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteOne provides a mock function with given fields: ctx, filter
 func (_m *CollectionHelper) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -96,3 +119,26 @@ func (_m *CollectionHelper) InsertOne(ctx context.Context, document interface{})
 
 	return r0, r1
 }
+
+// UpdateOne provides a mock function with given fields: ctx, filter, update
+func (_m *CollectionHelper) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	ret := _m.Called(ctx, filter, update)
+
+	var r0 *mongo.UpdateResult
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, interface{}) *mongo.UpdateResult); ok {
+		r0 = rf(ctx, filter, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.UpdateResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, interface{}) error); ok {
+		r1 = rf(ctx, filter, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
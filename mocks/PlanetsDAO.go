@@ -12,6 +12,20 @@ type PlanetsDAO struct {
 	mock.Mock
 }
 
+// AddFilm provides a mock function with given fields: ctx, id, filmID
+func (_m *PlanetsDAO) AddFilm(ctx context.Context, id string, filmID string) error {
+	ret := _m.Called(ctx, id, filmID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, filmID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: ctx, planets
 func (_m *PlanetsDAO) Create(ctx context.Context, planets *models.Planet) error {
 	ret := _m.Called(ctx, planets)
@@ -63,6 +77,52 @@ func (_m *PlanetsDAO) FindAll(ctx context.Context) ([]models.Planet, error) {
 	return r0, r1
 }
 
+// FindAllWithResidents provides a mock function with given fields: ctx
+func (_m *PlanetsDAO) FindAllWithResidents(ctx context.Context) ([]models.ExpandedPlanet, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.ExpandedPlanet
+	if rf, ok := ret.Get(0).(func(context.Context) []models.ExpandedPlanet); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ExpandedPlanet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByFilm provides a mock function with given fields: ctx, filmID
+func (_m *PlanetsDAO) FindByFilm(ctx context.Context, filmID string) ([]models.Planet, error) {
+	ret := _m.Called(ctx, filmID)
+
+	var r0 []models.Planet
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Planet); ok {
+		r0 = rf(ctx, filmID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Planet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, filmID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByID provides a mock function with given fields: cxt, id
 func (_m *PlanetsDAO) FindByID(cxt context.Context, id string) (*models.Planet, error) {
 	ret := _m.Called(cxt, id)
@@ -86,6 +146,29 @@ func (_m *PlanetsDAO) FindByID(cxt context.Context, id string) (*models.Planet,
 	return r0, r1
 }
 
+// FindByIDWithResidents provides a mock function with given fields: ctx, id
+func (_m *PlanetsDAO) FindByIDWithResidents(ctx context.Context, id string) (*models.ExpandedPlanet, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.ExpandedPlanet
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.ExpandedPlanet); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ExpandedPlanet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByName provides a mock function with given fields: cxt, name
 func (_m *PlanetsDAO) FindByName(cxt context.Context, name string) ([]models.Planet, error) {
 	ret := _m.Called(cxt, name)
@@ -108,3 +191,91 @@ func (_m *PlanetsDAO) FindByName(cxt context.Context, name string) ([]models.Pla
 
 	return r0, r1
 }
+
+// FindByNameWithResidents provides a mock function with given fields: ctx, name
+func (_m *PlanetsDAO) FindByNameWithResidents(ctx context.Context, name string) ([]models.ExpandedPlanet, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 []models.ExpandedPlanet
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.ExpandedPlanet); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ExpandedPlanet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFilm provides a mock function with given fields: ctx, id, filmID
+func (_m *PlanetsDAO) RemoveFilm(ctx context.Context, id string, filmID string) error {
+	ret := _m.Called(ctx, id, filmID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, filmID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Stats provides a mock function with given fields: ctx, groupBy, filter
+func (_m *PlanetsDAO) Stats(ctx context.Context, groupBy string, filter models.StatsFilter) (*models.PlanetStats, error) {
+	ret := _m.Called(ctx, groupBy, filter)
+
+	var r0 *models.PlanetStats
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.StatsFilter) *models.PlanetStats); ok {
+		r0 = rf(ctx, groupBy, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PlanetStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.StatsFilter) error); ok {
+		r1 = rf(ctx, groupBy, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnlinkFilm provides a mock function with given fields: ctx, filmID
+func (_m *PlanetsDAO) UnlinkFilm(ctx context.Context, filmID string) error {
+	ret := _m.Called(ctx, filmID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, filmID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertByName provides a mock function with given fields: ctx, planet
+func (_m *PlanetsDAO) UpsertByName(ctx context.Context, planet *models.Planet) error {
+	ret := _m.Called(ctx, planet)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Planet) error); ok {
+		r0 = rf(ctx, planet)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey grants its scopes to the requests sending the key, only the hash of
+// the key is stored and Prefix tells the keys apart
+type APIKey struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Prefix    string             `bson:"prefix" json:"prefix"`
+	Hash      string             `bson:"hash" json:"-"`
+	Scopes    []string           `bson:"scopes" json:"scopes"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	RotatedAt *time.Time         `bson:"rotated_at,omitempty" json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
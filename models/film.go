@@ -0,0 +1,11 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type Film struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Title       string             `bson:"title" json:"title"`
+	EpisodeID   int                `bson:"episode_id" json:"episodeId"`
+	Director    string             `bson:"director" json:"director"`
+	ReleaseDate string             `bson:"release_date" json:"releaseDate"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type Person struct {
+	ID        primitive.ObjectID  `bson:"_id" json:"id"`
+	Name      string              `bson:"name" json:"name"`
+	Gender    string              `bson:"gender" json:"gender"`
+	BirthYear string              `bson:"birth_year" json:"birthYear"`
+	Homeworld *primitive.ObjectID `bson:"homeworld,omitempty" json:"homeworld,omitempty"`
+}
+
+// ResidentSummary is the short representation of a person embedded in its homeworld
+type ResidentSummary struct {
+	ID   primitive.ObjectID `bson:"_id" json:"id"`
+	Name string             `bson:"name" json:"name"`
+}
@@ -3,9 +3,16 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Planet struct {
-	ID      primitive.ObjectID `bson:"_id" json:"id"`
-	Name    string             `bson:"name" json:"name"`
-	Climate string             `bson:"climate" json:"climate"`
-	Terrain string             `bson:"terrain" json:"terrain"`
-	Films   int                `bson:"films" json:"films"`
+	ID      primitive.ObjectID   `bson:"_id" json:"id"`
+	Name    string               `bson:"name" json:"name"`
+	Climate StringList           `bson:"climate" json:"climate"`
+	Terrain StringList           `bson:"terrain" json:"terrain"`
+	Films   int                  `bson:"films" json:"films"`
+	FilmIDs []primitive.ObjectID `bson:"film_ids,omitempty" json:"filmIds,omitempty"`
+}
+
+// ExpandedPlanet is a planet with its residents embedded
+type ExpandedPlanet struct {
+	Planet    `bson:",inline"`
+	Residents []ResidentSummary `bson:"residents" json:"residents"`
 }
@@ -0,0 +1,28 @@
+package models
+
+// StatsFilter restricts the planets taken into account by the statistics
+type StatsFilter struct {
+	Name     string
+	Climate  string
+	Terrain  string
+	MinFilms *int
+	MaxFilms *int
+}
+
+type PlanetStats struct {
+	GroupBy string       `json:"groupBy"`
+	Total   int          `json:"total"`
+	Films   FilmsSummary `json:"films"`
+	Groups  []StatsGroup `json:"groups"`
+}
+
+type FilmsSummary struct {
+	Avg float64 `bson:"avg" json:"avg"`
+	Min int     `bson:"min" json:"min"`
+	Max int     `bson:"max" json:"max"`
+}
+
+type StatsGroup struct {
+	Value interface{} `bson:"_id" json:"value"`
+	Count int         `bson:"count" json:"count"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// StringList is a list of normalized values (trimmed, lower case and without duplicates).
+// It also accepts the legacy comma separated strings such as "tundra, ice caves, mountain ranges".
+type StringList []string
+
+// NewStringList normalizes the given values
+func NewStringList(values ...string) StringList {
+	list := StringList{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		value = NormalizeListValue(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		list = append(list, value)
+	}
+	return list
+}
+
+// ParseStringList splits a legacy comma separated string into a list
+func ParseStringList(value string) StringList {
+	return NewStringList(strings.Split(value, ",")...)
+}
+
+func NormalizeListValue(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// String returns the legacy comma separated representation
+func (l StringList) String() string {
+	return strings.Join(l, ", ")
+}
+
+func (l StringList) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string(l.orEmpty()))
+}
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*l = ParseStringList(legacy)
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*l = NewStringList(values...)
+	return nil
+}
+
+func (l StringList) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue([]string(l.orEmpty()))
+}
+
+// UnmarshalBSONValue reads both arrays and the legacy comma separated strings
+func (l *StringList) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.String:
+		*l = ParseStringList(raw.StringValue())
+	case bsontype.Null, bsontype.Undefined:
+		*l = StringList{}
+	default:
+		var values []string
+		if err := raw.Unmarshal(&values); err != nil {
+			return err
+		}
+		*l = NewStringList(values...)
+	}
+	return nil
+}
+
+func (l StringList) orEmpty() StringList {
+	if l == nil {
+		return StringList{}
+	}
+	return l
+}
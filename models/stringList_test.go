@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStringList_UnmarshalJSON_with_legacy_string(t *testing.T) {
+	var planet Planet
+	err := json.Unmarshal([]byte(`{"climate":"Temperate, tropical","terrain":"jungle,, rainforests , jungle"}`), &planet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, StringList{"temperate", "tropical"}, planet.Climate)
+	assert.Equal(t, StringList{"jungle", "rainforests"}, planet.Terrain)
+}
+
+func TestStringList_UnmarshalJSON_with_list(t *testing.T) {
+	var planet Planet
+	err := json.Unmarshal([]byte(`{"climate":["Arid", " hot "]}`), &planet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, StringList{"arid", "hot"}, planet.Climate)
+}
+
+func TestStringList_MarshalJSON_without_values(t *testing.T) {
+	data, err := json.Marshal(struct{ Climate StringList }{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Climate":[]}`, string(data))
+}
+
+func TestStringList_UnmarshalBSONValue_with_legacy_string(t *testing.T) {
+	data, _ := bson.Marshal(bson.M{"climate": "frozen, Murky", "terrain": bson.A{"tundra", "ice caves"}})
+
+	var planet Planet
+	err := bson.Unmarshal(data, &planet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, StringList{"frozen", "murky"}, planet.Climate)
+	assert.Equal(t, StringList{"tundra", "ice caves"}, planet.Terrain)
+}
+
+func TestStringList_MarshalBSONValue(t *testing.T) {
+	data, err := bson.Marshal(Planet{Climate: StringList{"arid"}})
+	assert.NoError(t, err)
+
+	var document bson.M
+	assert.NoError(t, bson.Unmarshal(data, &document))
+	assert.Equal(t, bson.A{"arid"}, document["climate"])
+	assert.Equal(t, bson.A{}, document["terrain"])
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit refills a bucket of Burst tokens at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result tells whether a request was allowed and the state of its bucket:
+// Remaining tokens, Reset until the bucket is full again and RetryAfter until
+// the next token when the request wasn't allowed
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps a token bucket per key, e.g. per client and route group
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func New() *Limiter {
+	return &Limiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allow takes a token from the bucket of the key, a new key starts with a
+// full bucket
+func (l *Limiter) Allow(key string, limit Limit) Result {
+	return l.take(key, limit, 1)
+}
+
+// Peek tells whether Allow would allow a request of the key, without
+// taking a token
+func (l *Limiter) Peek(key string, limit Limit) Result {
+	return l.take(key, limit, 0)
+}
+
+func (l *Limiter) take(key string, limit Limit, tokens float64) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	capacity := float64(limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens -= tokens
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / limit.Rate)
+	return result
+}
+
+// Evict removes the buckets unused for longer than idle, which are full
+// again unless idle is shorter than their refill time, and returns how many
+func (l *Limiter) Evict(idle time.Duration) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	evicted := 0
+	for key, b := range l.buckets {
+		if l.now().Sub(b.last) > idle {
+			delete(l.buckets, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Reset removes every bucket, e.g. when the limits changed
+func (l *Limiter) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets = map[string]*bucket{}
+}
+
+// Len is the number of buckets
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
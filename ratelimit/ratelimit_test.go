@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter() (*Limiter, *time.Time) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New()
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter()
+	limit := Limit{Rate: 1, Burst: 2}
+
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, l.Allow("a", limit))
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, l.Allow("a", limit))
+	assert.Equal(t, Result{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}, l.Allow("a", limit))
+	assert.True(t, l.Allow("b", limit).Allowed, "the keys have their own bucket")
+
+	*now = now.Add(500 * time.Millisecond)
+	result := l.Allow("a", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	*now = now.Add(time.Minute)
+	result = l.Allow("a", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining, "the bucket doesn't refill over its burst")
+}
+
+func TestLimiter_Peek(t *testing.T) {
+	l, _ := newTestLimiter()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	assert.Equal(t, Result{Allowed: true, Limit: 1, Remaining: 1}, l.Peek("a", limit))
+	assert.Equal(t, Result{Allowed: true, Limit: 1, Remaining: 1}, l.Peek("a", limit), "peeking takes no token")
+	assert.True(t, l.Allow("a", limit).Allowed)
+	assert.Equal(t, Result{Allowed: false, Limit: 1, Remaining: 0, Reset: time.Second, RetryAfter: time.Second}, l.Peek("a", limit))
+}
+
+func TestLimiter_Evict(t *testing.T) {
+	l, now := newTestLimiter()
+	l.Allow("idle", Limit{Rate: 1, Burst: 1})
+	*now = now.Add(time.Minute)
+	l.Allow("active", Limit{Rate: 1, Burst: 1})
+	*now = now.Add(30 * time.Second)
+
+	assert.Equal(t, 1, l.Evict(time.Minute))
+	assert.Equal(t, 1, l.Len())
+
+	l.Reset()
+	assert.Equal(t, 0, l.Len())
+}
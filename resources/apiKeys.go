@@ -0,0 +1,105 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+)
+
+const (
+	INVALID_SCOPE_ERROR_MESSAGE = "Invalid scope"
+)
+
+type APIKeyHandler struct {
+	db dao.APIKeysDAO
+}
+
+func NewAPIKeyHandler(keys dao.APIKeysDAO) *APIKeyHandler {
+	return &APIKeyHandler{db: keys}
+}
+
+// createdAPIKey is the only response with the plaintext key
+type createdAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+func (h *APIKeyHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Debug("Finding all api keys")
+		keys, err := h.db.FindAll(r.Context())
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, keys)
+	}
+}
+
+func (h *APIKeyHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body struct {
+			Name   string
+			Scopes []string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" || len(body.Scopes) == 0 {
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			return
+		}
+		for _, scope := range body.Scopes {
+			if !auth.ValidScope(scope) {
+				errorHandler(w, r, errors.New(INVALID_SCOPE_ERROR_MESSAGE))
+				return
+			}
+		}
+		key, plaintext, err := auth.NewAPIKey(body.Name, body.Scopes)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).WithField("name", key.Name).Info("Creating an api key")
+		if err := h.db.Create(r.Context(), key); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusCreated, createdAPIKey{APIKey: *key, Key: plaintext})
+	}
+}
+
+// Rotate replaces a key by a new one with the same name and scopes
+func (h *APIKeyHandler) Rotate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		plaintext, prefix, hash, err := auth.GenerateKey()
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).Info("Rotating an api key")
+		key, err := h.db.Rotate(r.Context(), params["id"], prefix, hash)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, createdAPIKey{APIKey: *key, Key: plaintext})
+	}
+}
+
+func (h *APIKeyHandler) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Revoking an api key")
+		if err := h.db.Revoke(r.Context(), params["id"]); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, createSuccessResult())
+	}
+}
@@ -0,0 +1,96 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+)
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	keys := &mocks.APIKeysDAO{}
+	var stored *models.APIKey
+	keys.On("Create", mock.Anything, mock.Anything).Once().
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.APIKey) }).
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/keys", strings.NewReader(`{"name":"ci","scopes":["planets:read"]}`))
+	NewAPIKeyHandler(keys).Create()(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var body map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	assert.Equal(t, auth.HashKey(body["key"].(string)), stored.Hash)
+	assert.Equal(t, stored.Prefix, body["prefix"])
+	assert.NotContains(t, body, "hash")
+	keys.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_Create_admin(t *testing.T) {
+	keys := &mocks.APIKeysDAO{}
+	keys.On("Create", mock.Anything, mock.MatchedBy(func(key *models.APIKey) bool {
+		return key.Name == "ops" && len(key.Scopes) == 1 && key.Scopes[0] == auth.SCOPE_ADMIN
+	})).Once().Return(nil)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/keys", strings.NewReader(`{"name":"ops","scopes":["admin"]}`))
+	NewAPIKeyHandler(keys).Create()(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	keys.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_Create_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"missing name", `{"scopes":["planets:read"]}`, INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE},
+		{"missing scopes", `{"name":"ci"}`, INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE},
+		{"unknown scope", `{"name":"ci","scopes":["planets:delete"]}`, INVALID_SCOPE_ERROR_MESSAGE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/keys", strings.NewReader(tt.payload))
+			NewAPIKeyHandler(&mocks.APIKeysDAO{}).Create()(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.JSONEq(t, `{"error":"`+tt.want+`"}`, rr.Body.String())
+		})
+	}
+}
+
+func TestAPIKeyHandler_Rotate(t *testing.T) {
+	keys := &mocks.APIKeysDAO{}
+	keys.On("Rotate", mock.Anything, "5e27096d0c326694932a4cc8", mock.Anything, mock.Anything).Once().
+		Return(&models.APIKey{Name: "ci", Prefix: "swapi_abcdef"}, nil)
+	keys.On("Rotate", mock.Anything, "12345", mock.Anything, mock.Anything).Once().
+		Return(nil, errors.New(dao.INVALID_API_KEY_ID_ERROR_MESSAGE))
+	r := mux.NewRouter()
+	r.HandleFunc("/api/admin/keys/{id}/rotate", NewAPIKeyHandler(keys).Rotate())
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/admin/keys/5e27096d0c326694932a4cc8/rotate", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var body struct{ Key string }
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	assert.True(t, strings.HasPrefix(body.Key, auth.API_KEY_PREFIX))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/admin/keys/12345/rotate", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	keys.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	REQUEST_TIMEOUT_ERROR_MESSAGE       = "Request timed out"
+	CLIENT_CLOSED_REQUEST_ERROR_MESSAGE = "Client closed request"
+)
+
+const (
+	// STATUS_CLIENT_CLOSED_REQUEST is the nginx status for requests the client gave up on
+	STATUS_CLIENT_CLOSED_REQUEST = 499
+	// MAX_TIME_MS_EXPIRED_CODE is the mongo error code of a query stopped by maxTimeMS
+	MAX_TIME_MS_EXPIRED_CODE = 50
+)
+
+// contextError replaces the errors caused by the request context, the driver
+// doesn't always wrap them so their messages are checked as well
+func contextError(err error) error {
+	var commandError mongo.CommandError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		strings.HasSuffix(err.Error(), context.DeadlineExceeded.Error()),
+		errors.As(err, &commandError) && commandError.Code == MAX_TIME_MS_EXPIRED_CODE:
+		return errors.New(REQUEST_TIMEOUT_ERROR_MESSAGE)
+	case errors.Is(err, context.Canceled),
+		strings.HasSuffix(err.Error(), context.Canceled.Error()):
+		return errors.New(CLIENT_CLOSED_REQUEST_ERROR_MESSAGE)
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+)
+
+type FilmHandler struct {
+	db      dao.FilmsDAO
+	planets dao.PlanetsDAO
+}
+
+func NewFilmHandler(films dao.FilmsDAO, planets dao.PlanetsDAO) *FilmHandler {
+	return &FilmHandler{db: films, planets: planets}
+}
+
+func (h *FilmHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Debug("Finding all films")
+		films, err := h.db.FindAll(r.Context())
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, films)
+	}
+}
+
+func (h *FilmHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var film models.Film
+		if err := json.NewDecoder(r.Body).Decode(&film); err != nil {
+			logging.FromContext(r.Context()).Debug(err.Error(), film)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			return
+		}
+		idHelper := db.ObjectID()
+		film.ID = idHelper.NewObjectID()
+		logging.FromContext(r.Context()).Info("Creating a film")
+		if err := h.db.Create(r.Context(), &film); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		result := createSuccessResult()
+		respondWithJson(w, http.StatusCreated, result)
+	}
+}
+
+func (h *FilmHandler) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Finding a film by ID")
+		film, err := h.db.FindByID(r.Context(), params["id"])
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, film)
+	}
+}
+
+func (h *FilmHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		params := mux.Vars(r)
+		var film models.Film
+		if err := json.NewDecoder(r.Body).Decode(&film); err != nil {
+			logging.FromContext(r.Context()).Debug(err.Error(), film)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			return
+		}
+		logging.FromContext(r.Context()).Info("Updating a film")
+		if err := h.db.Update(r.Context(), params["id"], &film); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, film)
+	}
+}
+
+func (h *FilmHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body struct{ ID string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			logging.FromContext(r.Context()).Debug(err.Error(), body)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			return
+		}
+		logging.FromContext(r.Context()).Info("Deleting a film")
+		// the planets are unlinked first, a failed unlink leaves the film in
+		// place so deleting it again finishes the job
+		if err := h.planets.UnlinkFilm(r.Context(), body.ID); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		if err := h.db.Delete(r.Context(), body.ID); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		result := createSuccessResult()
+		respondWithJson(w, http.StatusOK, result)
+	}
+}
+
+// Planets lists the planets linked to a film
+func (h *FilmHandler) Planets() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		legacy, err := legacyFormat(r)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).Info("Finding the planets of a film")
+		if _, err := h.db.FindByID(r.Context(), params["id"]); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		planets, err := h.planets.FindByFilm(r.Context(), params["id"])
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithPlanets(w, r, http.StatusOK, planets, legacy)
+	}
+}
+
+// PlanetFilms lists the films linked to a planet
+func (h *FilmHandler) PlanetFilms() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Finding the films of a planet")
+		planet, err := h.planets.FindByID(r.Context(), params["id"])
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		films, err := h.db.FindByIDs(r.Context(), planet.FilmIDs)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, films)
+	}
+}
+
+// LinkPlanet links an existing film to a planet
+func (h *FilmHandler) LinkPlanet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Linking a film to a planet")
+		if _, err := h.db.FindByID(r.Context(), params["filmId"]); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		if err := h.planets.AddFilm(r.Context(), params["id"], params["filmId"]); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		result := createSuccessResult()
+		respondWithJson(w, http.StatusOK, result)
+	}
+}
+
+// UnlinkPlanet removes the link between a film and a planet
+func (h *FilmHandler) UnlinkPlanet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Unlinking a film from a planet")
+		if err := h.planets.RemoveFilm(r.Context(), params["id"], params["filmId"]); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		result := createSuccessResult()
+		respondWithJson(w, http.StatusOK, result)
+	}
+}
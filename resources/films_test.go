@@ -0,0 +1,301 @@
+package resources
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFilmHandler_GetAll(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/films", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filmDao := &mocks.FilmsDAO{}
+	dataMock := []models.Film{{Title: "A New Hope", EpisodeID: 4, Director: "George Lucas", ReleaseDate: "1977-05-25"}}
+	filmDao.
+		On("FindAll", mock.Anything).
+		Once().
+		Return(dataMock, nil)
+
+	rr := httptest.NewRecorder()
+	getAll := NewFilmHandler(filmDao, &mocks.PlanetsDAO{}).GetAll()
+	handler := http.HandlerFunc(getAll)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"id":"000000000000000000000000","title":"A New Hope","episodeId":4,"director":"George Lucas","releaseDate":"1977-05-25"}]`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestFilmHandler_Create(t *testing.T) {
+	payload := `{"title":"mocked-film","episodeId":4}`
+
+	req, err := http.NewRequest(http.MethodPost, "/api/films", bytes.NewBuffer([]byte(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-type", "application/json")
+
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("Create", mock.Anything, mock.Anything).
+		Once().
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	create := NewFilmHandler(filmDao, &mocks.PlanetsDAO{}).Create()
+	handler := http.HandlerFunc(create)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	expected := `{"result":"success"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestFilmHandler_Update_with_not_found(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+	payload := `{"title":"mocked-film"}`
+
+	req, err := http.NewRequest(http.MethodPut, "/api/films/"+id, bytes.NewBuffer([]byte(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("Update", mock.Anything, id, mock.Anything).
+		Once().
+		Return(errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/films/{id}", NewFilmHandler(filmDao, &mocks.PlanetsDAO{}).Update())
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+
+	expected := `{"error":"document not found"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestFilmHandler_Delete(t *testing.T) {
+	id := "5e270a857247f2102f213565"
+	payload := fmt.Sprintf(`{"id": "%s"}`, id)
+
+	req, err := http.NewRequest(http.MethodDelete, "/api/films", bytes.NewBuffer([]byte(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("Delete", mock.Anything, id).
+		Once().
+		Return(nil)
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("UnlinkFilm", mock.Anything, id).
+		Once().
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	delete := NewFilmHandler(filmDao, planetDao).Delete()
+	handler := http.HandlerFunc(delete)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `{"result":"success"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+	planetDao.AssertExpectations(t)
+}
+
+func TestFilmHandler_Delete_with_unlink_error(t *testing.T) {
+	id := "5e270a857247f2102f213565"
+	payload := fmt.Sprintf(`{"id": "%s"}`, id)
+
+	req, err := http.NewRequest(http.MethodDelete, "/api/films", bytes.NewBuffer([]byte(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filmDao := &mocks.FilmsDAO{}
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("UnlinkFilm", mock.Anything, id).
+		Once().
+		Return(errors.New("mocked-error"))
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(NewFilmHandler(filmDao, planetDao).Delete())
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	filmDao.AssertNotCalled(t, "Delete", mock.Anything, id)
+}
+
+func TestFilmHandler_Planets(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+
+	req, err := http.NewRequest(http.MethodGet, "/api/films/"+id+"/planets", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("FindByID", mock.Anything, id).
+		Once().
+		Return(&models.Film{}, nil)
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("FindByFilm", mock.Anything, id).
+		Once().
+		Return([]models.Planet{{Name: "mocked-planet", Films: 1}}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/films/{id}/planets", NewFilmHandler(filmDao, planetDao).Planets())
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"id":"000000000000000000000000","name":"mocked-planet","climate":[],"terrain":[],"films":1}]`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestFilmHandler_PlanetFilms(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+	filmID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cca")
+
+	req, err := http.NewRequest(http.MethodGet, "/api/planets/"+id+"/films", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("FindByID", mock.Anything, id).
+		Once().
+		Return(&models.Planet{FilmIDs: []primitive.ObjectID{filmID}}, nil)
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("FindByIDs", mock.Anything, []primitive.ObjectID{filmID}).
+		Once().
+		Return([]models.Film{{ID: filmID, Title: "mocked-film"}}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/planets/{id}/films", NewFilmHandler(filmDao, planetDao).PlanetFilms())
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"id":"5e27096d0c326694932a4cca","title":"mocked-film","episodeId":0,"director":"","releaseDate":""}]`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestFilmHandler_LinkPlanet_with_invalid_film_id(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+
+	req, err := http.NewRequest(http.MethodPut, "/api/planets/"+id+"/films/invalid", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("FindByID", mock.Anything, "invalid").
+		Once().
+		Return(nil, errors.New(dao.INVALID_FILM_ID_ERROR_MESSAGE))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/planets/{id}/films/{filmId}", NewFilmHandler(filmDao, &mocks.PlanetsDAO{}).LinkPlanet())
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	expected := `{"error":"Invalid Film ID"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestFilmHandler_UnlinkPlanet(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+	filmID := "5e27096d0c326694932a4cca"
+
+	req, err := http.NewRequest(http.MethodDelete, "/api/planets/"+id+"/films/"+filmID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("RemoveFilm", mock.Anything, id, filmID).
+		Once().
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/planets/{id}/films/{filmId}", NewFilmHandler(&mocks.FilmsDAO{}, planetDao).UnlinkPlanet())
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `{"result":"success"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
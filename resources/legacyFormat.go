@@ -0,0 +1,84 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wallacebenevides/star-wars-api/features"
+)
+
+// fields rendered as comma separated strings for the clients using ?legacyFormat=true
+var legacyListFields = []string{"climate", "terrain"}
+
+// respondWithPlanets writes planets in the list format, or in the legacy comma separated
+// format when legacy, see legacyFormat
+func respondWithPlanets(w http.ResponseWriter, r *http.Request, code int, payload interface{}, legacy bool) {
+	traceResponse(r, func() { writePlanets(w, r, code, payload, legacy) })
+}
+
+func writePlanets(w http.ResponseWriter, r *http.Request, code int, payload interface{}, legacy bool) {
+	if !legacy {
+		respondWithJson(w, code, payload)
+		return
+	}
+	converted, err := toLegacyFormat(payload)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+	respondWithJson(w, code, converted)
+}
+
+// legacyFormat tells whether the request has ?legacyFormat=true, the handlers
+// read it before querying so an invalid value costs no database call
+func legacyFormat(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("legacyFormat")
+	if value == "" {
+		return false, nil
+	}
+	legacy, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New(INVALID_QUERY_PARAMETER_ERROR_MESSAGE)
+	}
+	if legacy && !features.FromContext(r.Context()).LegacyFormat {
+		return false, errors.New(FEATURE_DISABLED_ERROR_MESSAGE)
+	}
+	return legacy, nil
+}
+
+func toLegacyFormat(payload interface{}) (interface{}, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	joinListFields(document)
+	return document, nil
+}
+
+func joinListFields(document interface{}) {
+	switch value := document.(type) {
+	case []interface{}:
+		for _, item := range value {
+			joinListFields(item)
+		}
+	case map[string]interface{}:
+		for _, field := range legacyListFields {
+			if list, ok := value[field].([]interface{}); ok {
+				values := make([]string, 0, len(list))
+				for _, item := range list {
+					if s, ok := item.(string); ok {
+						values = append(values, s)
+					}
+				}
+				value[field] = strings.Join(values, ", ")
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/models"
+)
+
+const (
+	INVALID_HOMEWORLD_ERROR_MESSAGE = "Invalid homeworld"
+)
+
+type PersonHandler struct {
+	db      dao.PeopleDAO
+	planets dao.PlanetsDAO
+}
+
+func NewPersonHandler(people dao.PeopleDAO, planets dao.PlanetsDAO) *PersonHandler {
+	return &PersonHandler{db: people, planets: planets}
+}
+
+func (h *PersonHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Debug("Finding all people")
+		people, err := h.db.FindAll(r.Context())
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, people)
+	}
+}
+
+func (h *PersonHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var person models.Person
+		if err := json.NewDecoder(r.Body).Decode(&person); err != nil {
+			logging.FromContext(r.Context()).Debug(err.Error(), person)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			return
+		}
+		if person.Homeworld != nil {
+			if _, err := h.planets.FindByID(r.Context(), person.Homeworld.Hex()); err != nil {
+				if err.Error() == dao.NOT_FOUND_ERROR_MESSAGE {
+					err = errors.New(INVALID_HOMEWORLD_ERROR_MESSAGE)
+				}
+				errorHandler(w, r, err)
+				return
+			}
+		}
+		idHelper := db.ObjectID()
+		person.ID = idHelper.NewObjectID()
+		logging.FromContext(r.Context()).Info("Creating a person")
+		if err := h.db.Create(r.Context(), &person); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		result := createSuccessResult()
+		respondWithJson(w, http.StatusCreated, result)
+	}
+}
+
+func (h *PersonHandler) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Finding a person by ID")
+		person, err := h.db.FindByID(r.Context(), params["id"])
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, person)
+	}
+}
+
+func (h *PersonHandler) FindByName() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		logging.FromContext(r.Context()).Info("Finding people by name")
+		people, err := h.db.FindByName(r.Context(), name)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		if len(people) == 0 {
+			errorHandler(w, r, errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
+			return
+		}
+		respondWithJson(w, http.StatusOK, people)
+	}
+}
+
+func (h *PersonHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body struct{ ID string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			logging.FromContext(r.Context()).Debug(err.Error(), body)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			return
+		}
+		logging.FromContext(r.Context()).Info("Deleting a person")
+		if err := h.db.Delete(r.Context(), body.ID); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		result := createSuccessResult()
+		respondWithJson(w, http.StatusOK, result)
+	}
+}
+
+// Residents lists the people whose homeworld is the given planet
+func (h *PersonHandler) Residents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		logging.FromContext(r.Context()).Info("Finding the residents of a planet")
+		if _, err := h.planets.FindByID(r.Context(), params["id"]); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		people, err := h.db.FindByHomeworld(r.Context(), params["id"])
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithJson(w, http.StatusOK, people)
+	}
+}
@@ -0,0 +1,146 @@
+package resources
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPersonHandler_GetAll(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/people", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planetID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc8")
+	peopleDao := &mocks.PeopleDAO{}
+	dataMock := []models.Person{{Name: "Luke Skywalker", Gender: "male", BirthYear: "19BBY", Homeworld: &planetID}}
+	peopleDao.
+		On("FindAll", mock.Anything).
+		Once().
+		Return(dataMock, nil)
+
+	rr := httptest.NewRecorder()
+	getAll := NewPersonHandler(peopleDao, &mocks.PlanetsDAO{}).GetAll()
+	handler := http.HandlerFunc(getAll)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"id":"000000000000000000000000","name":"Luke Skywalker","gender":"male","birthYear":"19BBY","homeworld":"5e27096d0c326694932a4cc8"}]`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPersonHandler_Create(t *testing.T) {
+	payload := `{"name":"Luke Skywalker","homeworld":"5e27096d0c326694932a4cc8"}`
+
+	req, err := http.NewRequest(http.MethodPost, "/api/people", bytes.NewBuffer([]byte(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("FindByID", mock.Anything, "5e27096d0c326694932a4cc8").
+		Once().
+		Return(&models.Planet{}, nil)
+	peopleDao := &mocks.PeopleDAO{}
+	peopleDao.
+		On("Create", mock.Anything, mock.Anything).
+		Once().
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	create := NewPersonHandler(peopleDao, planetDao).Create()
+	handler := http.HandlerFunc(create)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	expected := `{"result":"success"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPersonHandler_Create_with_unknown_homeworld(t *testing.T) {
+	payload := `{"name":"Luke Skywalker","homeworld":"5e27096d0c326694932a4cc8"}`
+
+	req, err := http.NewRequest(http.MethodPost, "/api/people", bytes.NewBuffer([]byte(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("FindByID", mock.Anything, "5e27096d0c326694932a4cc8").
+		Once().
+		Return(nil, errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
+
+	rr := httptest.NewRecorder()
+	create := NewPersonHandler(&mocks.PeopleDAO{}, planetDao).Create()
+	handler := http.HandlerFunc(create)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	expected := `{"error":"Invalid homeworld"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPersonHandler_Residents(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+
+	req, err := http.NewRequest(http.MethodGet, "/api/planets/"+id+"/residents", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("FindByID", mock.Anything, id).
+		Once().
+		Return(&models.Planet{}, nil)
+	peopleDao := &mocks.PeopleDAO{}
+	peopleDao.
+		On("FindByHomeworld", mock.Anything, id).
+		Once().
+		Return([]models.Person{{Name: "Owen Lars"}}, nil)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/planets/{id}/residents", NewPersonHandler(peopleDao, planetDao).Residents())
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"id":"000000000000000000000000","name":"Owen Lars","gender":"","birthYear":""}]`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
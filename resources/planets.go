@@ -1,15 +1,18 @@
 package resources
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
-	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/breaker"
 	"github.com/wallacebenevides/star-wars-api/dao"
 	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/features"
+	"github.com/wallacebenevides/star-wars-api/logging"
 	"github.com/wallacebenevides/star-wars-api/models"
 )
 
@@ -20,6 +23,12 @@ type PlanetHandler struct {
 const (
 	INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE = "Invalid request payload"
 	INTERNAL_SERVER_ERROR_MESSAGE         = "Operation could not be performed"
+	INVALID_QUERY_PARAMETER_ERROR_MESSAGE = "Invalid query parameter"
+	FEATURE_DISABLED_ERROR_MESSAGE        = "Feature disabled"
+)
+
+const (
+	EXPAND_RESIDENTS = "residents"
 )
 
 func NewPlanetHandler(dao dao.PlanetsDAO) *PlanetHandler {
@@ -28,30 +37,51 @@ func NewPlanetHandler(dao dao.PlanetsDAO) *PlanetHandler {
 
 func (h *PlanetHandler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Debug("Finding all planets")
-		planets, err := h.db.FindAll(context.TODO())
+		r, span := startSpan(r, "PlanetHandler.GetAll")
+		defer span.End()
+		expand, err := expandResidents(r)
 		if err != nil {
-			errorHandler(w, err)
+			errorHandler(w, r, err)
 			return
 		}
-		respondWithJson(w, http.StatusOK, planets)
+		legacy, err := legacyFormat(r)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).Debug("Finding all planets")
+		var planets interface{}
+		if expand {
+			planets, err = h.db.FindAllWithResidents(r.Context())
+		} else {
+			planets, err = h.db.FindAll(r.Context())
+		}
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithPlanets(w, r, http.StatusOK, planets, legacy)
 	}
 }
 
 func (h *PlanetHandler) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r, span := startSpan(r, "PlanetHandler.Create")
+		defer span.End()
 		defer r.Body.Close()
 		var planet models.Planet
 		if err := json.NewDecoder(r.Body).Decode(&planet); err != nil {
-			log.Debug(err.Error(), planet)
-			errorHandler(w, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			logging.FromContext(r.Context()).Debug(err.Error(), planet)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
 			return
 		}
 		idHelper := db.ObjectID()
 		planet.ID = idHelper.NewObjectID()
-		log.Info("Creating a planet")
-		if err := h.db.Create(context.TODO(), &planet); err != nil {
-			errorHandler(w, err)
+		// films are linked through /planets/{id}/films/{filmId}
+		planet.FilmIDs = nil
+		logging.FromContext(r.Context()).Info("Creating a planet")
+		if err := h.db.Create(r.Context(), &planet); err != nil {
+			errorHandler(w, r, err)
 			return
 		}
 		result := createSuccessResult()
@@ -61,47 +91,124 @@ func (h *PlanetHandler) Create() http.HandlerFunc {
 
 func (h *PlanetHandler) GetByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r, span := startSpan(r, "PlanetHandler.GetByID")
+		defer span.End()
 		params := mux.Vars(r)
-		log.Info("Finding a planet by ID")
-		planet, err := h.db.FindByID(context.TODO(), params["id"])
+		expand, err := expandResidents(r)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		legacy, err := legacyFormat(r)
 		if err != nil {
-			errorHandler(w, err)
+			errorHandler(w, r, err)
 			return
 		}
-		respondWithJson(w, http.StatusOK, planet)
+		logging.FromContext(r.Context()).Info("Finding a planet by ID")
+		var planet interface{}
+		if expand {
+			planet, err = h.db.FindByIDWithResidents(r.Context(), params["id"])
+		} else {
+			planet, err = h.db.FindByID(r.Context(), params["id"])
+		}
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		respondWithPlanets(w, r, http.StatusOK, planet, legacy)
 	}
 }
 
 func (h *PlanetHandler) FindByName() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r, span := startSpan(r, "PlanetHandler.FindByName")
+		defer span.End()
 		name := r.URL.Query().Get("name")
-		log.Info("Finding planets by name")
-		planets, err := h.db.FindByName(context.TODO(), name)
+		expand, err := expandResidents(r)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		legacy, err := legacyFormat(r)
 		if err != nil {
-			errorHandler(w, err)
+			errorHandler(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).Info("Finding planets by name")
+		if expand {
+			h.findByNameWithResidents(w, r, name, legacy)
+			return
+		}
+		planets, err := h.db.FindByName(r.Context(), name)
+		if err != nil {
+			errorHandler(w, r, err)
 			return
 		}
 		if len(planets) == 0 {
-			errorHandler(w, errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
+			errorHandler(w, r, errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
 			return
 		}
-		respondWithJson(w, http.StatusOK, planets)
+		respondWithPlanets(w, r, http.StatusOK, planets, legacy)
+	}
+}
+
+func (h *PlanetHandler) findByNameWithResidents(w http.ResponseWriter, r *http.Request, name string, legacy bool) {
+	planets, err := h.db.FindByNameWithResidents(r.Context(), name)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+	if len(planets) == 0 {
+		errorHandler(w, r, errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
+		return
+	}
+	respondWithPlanets(w, r, http.StatusOK, planets, legacy)
+}
+
+func (h *PlanetHandler) Stats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, span := startSpan(r, "PlanetHandler.Stats")
+		defer span.End()
+		query := r.URL.Query()
+		filter := models.StatsFilter{
+			Name:    query.Get("name"),
+			Climate: query.Get("climate"),
+			Terrain: query.Get("terrain"),
+		}
+		var err error
+		if filter.MinFilms, err = intQueryParam(query.Get("minFilms")); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		if filter.MaxFilms, err = intQueryParam(query.Get("maxFilms")); err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		logging.FromContext(r.Context()).Info("Computing planets statistics")
+		stats, err := h.db.Stats(r.Context(), query.Get("groupBy"), filter)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		traceResponse(r, func() { respondWithJson(w, http.StatusOK, stats) })
 	}
 }
 
 func (h *PlanetHandler) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r, span := startSpan(r, "PlanetHandler.Delete")
+		defer span.End()
 		defer r.Body.Close()
 		var body struct{ ID string }
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			log.Debug(err.Error(), body)
-			errorHandler(w, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
+			logging.FromContext(r.Context()).Debug(err.Error(), body)
+			errorHandler(w, r, errors.New(INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE))
 			return
 		}
 		// Declare a primitive ObjectID from a hexadecimal string
-		log.Info("Deleting a planet")
-		if err := h.db.Delete(context.TODO(), body.ID); err != nil {
-			errorHandler(w, err)
+		logging.FromContext(r.Context()).Info("Deleting a planet")
+		if err := h.db.Delete(r.Context(), body.ID); err != nil {
+			errorHandler(w, r, err)
 			return
 		}
 		result := createSuccessResult()
@@ -109,15 +216,35 @@ func (h *PlanetHandler) Delete() http.HandlerFunc {
 	}
 }
 
-func errorHandler(w http.ResponseWriter, err error) {
-	switch err.Error() {
+func errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	switch err = contextError(err); err.Error() {
 	case dao.INVALID_ID_ERROR_MESSAGE,
-		INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE:
+		dao.INVALID_FILM_ID_ERROR_MESSAGE,
+		dao.INVALID_PERSON_ID_ERROR_MESSAGE,
+		dao.INVALID_API_KEY_ID_ERROR_MESSAGE,
+		INVALID_SCOPE_ERROR_MESSAGE,
+		INVALID_HOMEWORLD_ERROR_MESSAGE,
+		dao.INVALID_GROUP_BY_ERROR_MESSAGE,
+		INVALID_REQUEST_PAYLOAD_ERROR_MESSAGE,
+		INVALID_QUERY_PARAMETER_ERROR_MESSAGE,
+		FEATURE_DISABLED_ERROR_MESSAGE:
 		respondWithError(w, http.StatusBadRequest, err.Error())
 	case dao.NOT_FOUND_ERROR_MESSAGE:
 		respondWithError(w, http.StatusNotFound, err.Error())
+	case breaker.OPEN_ERROR_MESSAGE:
+		var open *breaker.OpenError
+		if errors.As(err, &open) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(open.RetryAfter.Seconds()))))
+		}
+		respondWithError(w, http.StatusServiceUnavailable, err.Error())
+	case REQUEST_TIMEOUT_ERROR_MESSAGE:
+		logging.FromContext(r.Context()).Warn(err)
+		respondWithError(w, http.StatusGatewayTimeout, err.Error())
+	case CLIENT_CLOSED_REQUEST_ERROR_MESSAGE:
+		logging.FromContext(r.Context()).Debug(err)
+		respondWithError(w, STATUS_CLIENT_CLOSED_REQUEST, err.Error())
 	default:
-		log.Error(err)
+		logging.FromContext(r.Context()).Error(err)
 		respondWithError(w, http.StatusInternalServerError, INTERNAL_SERVER_ERROR_MESSAGE)
 	}
 }
@@ -136,3 +263,31 @@ func respondWithJson(w http.ResponseWriter, code int, payload interface{}) {
 func createSuccessResult() map[string]string {
 	return map[string]string{"result": "success"}
 }
+
+// intQueryParam parses an optional integer query parameter, returning nil when it is absent
+func intQueryParam(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.New(INVALID_QUERY_PARAMETER_ERROR_MESSAGE)
+	}
+	return &n, nil
+}
+
+// expandResidents tells whether the planet residents were requested through
+// ?expand=residents, unless the feature is disabled
+func expandResidents(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("expand") {
+	case "":
+		return false, nil
+	case EXPAND_RESIDENTS:
+		if !features.FromContext(r.Context()).ExpandResidents {
+			return false, errors.New(FEATURE_DISABLED_ERROR_MESSAGE)
+		}
+		return true, nil
+	default:
+		return false, errors.New(INVALID_QUERY_PARAMETER_ERROR_MESSAGE)
+	}
+}
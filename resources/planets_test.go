@@ -8,14 +8,23 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/breaker"
+	"github.com/wallacebenevides/star-wars-api/config"
 	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/features"
 	"github.com/wallacebenevides/star-wars-api/mocks"
 	"github.com/wallacebenevides/star-wars-api/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestPlanetHandler_GetAll(t *testing.T) {
@@ -26,7 +35,7 @@ func TestPlanetHandler_GetAll(t *testing.T) {
 	planetDao := &mocks.PlanetsDAO{}
 	dataMock := []models.Planet{{Name: "mocked-planet"}}
 	planetDao.
-		On("FindAll", context.TODO()).
+		On("FindAll", mock.Anything).
 		Once().
 		Return(dataMock, nil)
 
@@ -42,7 +51,7 @@ func TestPlanetHandler_GetAll(t *testing.T) {
 
 	// Check the response body is what we expect.
 	got := rr.Body.String()
-	expected := `[{"id":"000000000000000000000000","name":"mocked-planet","climate":"","terrain":"","films":0}]`
+	expected := `[{"id":"000000000000000000000000","name":"mocked-planet","climate":[],"terrain":[],"films":0}]`
 
 	assert.Equal(t, expected, got)
 }
@@ -54,7 +63,7 @@ func TestPlanetHandler_GetAll_with_error(t *testing.T) {
 	}
 	planetDao := &mocks.PlanetsDAO{}
 	planetDao.
-		On("FindAll", context.TODO()).
+		On("FindAll", mock.Anything).
 		Once().
 		Return(nil, errors.New("mocked-error"))
 
@@ -70,7 +79,7 @@ func TestPlanetHandler_GetAll_with_error(t *testing.T) {
 
 	// Check the response body is what we expect.
 	got := rr.Body.String()
-	expected := `{"error":"mocked-error"}`
+	expected := fmt.Sprintf(`{"error":"%s"}`, INTERNAL_SERVER_ERROR_MESSAGE)
 
 	assert.Equal(t, expected, got)
 }
@@ -91,7 +100,7 @@ func TestPlanetHandler_Create(t *testing.T) {
 	objectID.On("NewObjectID").Return(id)
 
 	planetDao.
-		On("Create", context.TODO(), mock.Anything).
+		On("Create", mock.Anything, mock.Anything).
 		Once().
 		Return(nil)
 
@@ -128,7 +137,7 @@ func TestPlanetHandler_Create_with_error(t *testing.T) {
 	objectID.On("NewObjectID").Return(id)
 
 	planetDao.
-		On("Create", context.TODO(), mock.Anything).
+		On("Create", mock.Anything, mock.Anything).
 		Once().
 		Return(errors.New("mocked-error"))
 
@@ -143,7 +152,7 @@ func TestPlanetHandler_Create_with_error(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
 	}
 
-	expected := `{"error":"mocked-error"}`
+	expected := fmt.Sprintf(`{"error":"%s"}`, INTERNAL_SERVER_ERROR_MESSAGE)
 	got := rr.Body.String()
 
 	assert.Equal(t, expected, got)
@@ -194,7 +203,7 @@ func TestPlanetHandler_GetByID(t *testing.T) {
 	planetDao := &mocks.PlanetsDAO{}
 	dataMock := models.Planet{ID: objectID}
 	planetDao.
-		On("FindByID", context.TODO(), id).
+		On("FindByID", mock.Anything, id).
 		Once().
 		Return(&dataMock, nil)
 
@@ -210,7 +219,7 @@ func TestPlanetHandler_GetByID(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	expected := `{"id":"5e27096d0c326694932a4cc8","name":"","climate":"","terrain":"","films":0}`
+	expected := `{"id":"5e27096d0c326694932a4cc8","name":"","climate":[],"terrain":[],"films":0}`
 	got := rr.Body.String()
 
 	assert.Equal(t, expected, got)
@@ -227,7 +236,7 @@ func TestPlanetHandler_GetByID_with_error(t *testing.T) {
 
 	planetDao := &mocks.PlanetsDAO{}
 	planetDao.
-		On("FindByID", context.TODO(), id).
+		On("FindByID", mock.Anything, id).
 		Once().
 		Return(nil, errors.New("mocked-error"))
 
@@ -243,7 +252,7 @@ func TestPlanetHandler_GetByID_with_error(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
 	}
 
-	expected := `{"error":"mocked-error"}`
+	expected := fmt.Sprintf(`{"error":"%s"}`, INTERNAL_SERVER_ERROR_MESSAGE)
 	got := rr.Body.String()
 
 	assert.Equal(t, expected, got)
@@ -260,7 +269,7 @@ func TestPlanetHandler_GetByID_with_bad_request_error(t *testing.T) {
 
 	planetDao := &mocks.PlanetsDAO{}
 	planetDao.
-		On("FindByID", context.TODO(), id).
+		On("FindByID", mock.Anything, id).
 		Once().
 		Return(nil, errors.New(dao.INVALID_ID_ERROR_MESSAGE))
 
@@ -293,7 +302,7 @@ func TestPlanetHandler_GetByID_with_not_found(t *testing.T) {
 
 	planetDao := &mocks.PlanetsDAO{}
 	planetDao.
-		On("FindByID", context.TODO(), id).
+		On("FindByID", mock.Anything, id).
 		Return(nil, errors.New(dao.NOT_FOUND_ERROR_MESSAGE))
 
 	rr := httptest.NewRecorder()
@@ -326,7 +335,7 @@ func TestPlanetHandler_FindByName(t *testing.T) {
 	dataMock := []models.Planet{{Name: "mocked-planet"}}
 
 	planetDao.
-		On("FindByName", context.TODO(), name).
+		On("FindByName", mock.Anything, name).
 		Once().
 		Return(dataMock, nil)
 
@@ -341,7 +350,7 @@ func TestPlanetHandler_FindByName(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	expected := `[{"id":"000000000000000000000000","name":"mocked-planet","climate":"","terrain":"","films":0}]`
+	expected := `[{"id":"000000000000000000000000","name":"mocked-planet","climate":[],"terrain":[],"films":0}]`
 
 	got := rr.Body.String()
 
@@ -359,7 +368,7 @@ func TestPlanetHandler_FindByName_with_error(t *testing.T) {
 	planetDao := &mocks.PlanetsDAO{}
 
 	planetDao.
-		On("FindByName", context.TODO(), name).
+		On("FindByName", mock.Anything, name).
 		Once().
 		Return(nil, errors.New("mocked-error"))
 
@@ -374,7 +383,7 @@ func TestPlanetHandler_FindByName_with_error(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
 	}
 
-	expected := `{"error":"mocked-error"}`
+	expected := fmt.Sprintf(`{"error":"%s"}`, INTERNAL_SERVER_ERROR_MESSAGE)
 
 	got := rr.Body.String()
 
@@ -393,7 +402,7 @@ func TestPlanetHandler_FindByName_with_not_found(t *testing.T) {
 	dataMock := []models.Planet{}
 
 	planetDao.
-		On("FindByName", context.TODO(), name).
+		On("FindByName", mock.Anything, name).
 		Once().
 		Return(dataMock, nil)
 
@@ -429,7 +438,7 @@ func TestPlanetHandler_Delete(t *testing.T) {
 	planetDao := &mocks.PlanetsDAO{}
 
 	planetDao.
-		On("Delete", context.TODO(), id).
+		On("Delete", mock.Anything, id).
 		Once().
 		Return(nil)
 
@@ -545,3 +554,304 @@ func TestPlanetHandler_Delete_with_not_found(t *testing.T) {
 
 	assert.Equal(t, expected, got)
 }
+
+func TestPlanetHandler_Stats(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/planets/stats?groupBy=terrain&climate=arid&minFilms=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	minFilms := 1
+	filter := models.StatsFilter{Climate: "arid", MinFilms: &minFilms}
+	dataMock := &models.PlanetStats{
+		GroupBy: "terrain",
+		Total:   1,
+		Films:   models.FilmsSummary{Avg: 1, Min: 1, Max: 1},
+		Groups:  []models.StatsGroup{{Value: "desert", Count: 1}},
+	}
+	planetDao.
+		On("Stats", mock.Anything, "terrain", filter).
+		Once().
+		Return(dataMock, nil)
+
+	rr := httptest.NewRecorder()
+	stats := NewPlanetHandler(planetDao).Stats()
+	handler := http.HandlerFunc(stats)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `{"groupBy":"terrain","total":1,"films":{"avg":1,"min":1,"max":1},"groups":[{"value":"desert","count":1}]}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPlanetHandler_Stats_with_bad_request_error(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/planets/stats?minFilms=many", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+
+	rr := httptest.NewRecorder()
+	stats := NewPlanetHandler(planetDao).Stats()
+	handler := http.HandlerFunc(stats)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	expected := `{"error":"Invalid query parameter"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPlanetHandler_Stats_with_invalid_group_by(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/planets/stats?groupBy=name", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("Stats", mock.Anything, "name", models.StatsFilter{}).
+		Once().
+		Return(nil, errors.New(dao.INVALID_GROUP_BY_ERROR_MESSAGE))
+
+	rr := httptest.NewRecorder()
+	stats := NewPlanetHandler(planetDao).Stats()
+	handler := http.HandlerFunc(stats)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	expected := `{"error":"Invalid groupBy parameter"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPlanetHandler_GetByID_with_expanded_residents(t *testing.T) {
+	id := "5e27096d0c326694932a4cc8"
+	path := fmt.Sprintf("/api/planets/%s?expand=residents", id)
+
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	residentID, _ := primitive.ObjectIDFromHex("5e27096d0c326694932a4cc9")
+	planetDao := &mocks.PlanetsDAO{}
+	dataMock := models.ExpandedPlanet{
+		Planet:    models.Planet{Name: "Tatooine"},
+		Residents: []models.ResidentSummary{{ID: residentID, Name: "Luke Skywalker"}},
+	}
+	planetDao.
+		On("FindByIDWithResidents", mock.Anything, id).
+		Once().
+		Return(&dataMock, nil)
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	getByID := NewPlanetHandler(planetDao).GetByID()
+	router.HandleFunc("/api/planets/{id}", getByID)
+	router.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `{"id":"000000000000000000000000","name":"Tatooine","climate":[],"terrain":[],"films":0,"residents":[{"id":"5e27096d0c326694932a4cc9","name":"Luke Skywalker"}]}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPlanetHandler_GetAll_with_invalid_expand(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/planets?expand=moons", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	getAll := NewPlanetHandler(&mocks.PlanetsDAO{}).GetAll()
+	handler := http.HandlerFunc(getAll)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	expected := `{"error":"Invalid query parameter"}`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPlanetHandler_GetAll_with_disabled_expand(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/planets?expand=residents", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(features.WithFeatures(req.Context(), config.Features{LegacyFormat: true}))
+
+	rr := httptest.NewRecorder()
+	NewPlanetHandler(&mocks.PlanetsDAO{}).GetAll().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, `{"error":"Feature disabled"}`, rr.Body.String())
+}
+
+func TestPlanetHandler_GetAll_with_invalid_legacy_format(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		features config.Features
+		want     string
+	}{
+		{"invalid value", "?legacyFormat=maybe", features.ALL, `{"error":"Invalid query parameter"}`},
+		{"disabled", "?legacyFormat=true", config.Features{ExpandResidents: true}, `{"error":"Feature disabled"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/planets"+tt.query, nil)
+			req = req.WithContext(features.WithFeatures(req.Context(), tt.features))
+			planetDao := &mocks.PlanetsDAO{}
+
+			rr := httptest.NewRecorder()
+			NewPlanetHandler(planetDao).GetAll().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, tt.want, rr.Body.String())
+			planetDao.AssertNotCalled(t, "FindAll", mock.Anything)
+		})
+	}
+}
+
+func TestPlanetHandler_GetAll_with_legacy_format(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/api/planets?legacyFormat=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planetDao := &mocks.PlanetsDAO{}
+	dataMock := []models.Planet{{Name: "Hoth", Climate: models.StringList{"frozen"}, Terrain: models.StringList{"tundra", "ice caves"}}}
+	planetDao.
+		On("FindAll", mock.Anything).
+		Once().
+		Return(dataMock, nil)
+
+	rr := httptest.NewRecorder()
+	getAll := NewPlanetHandler(planetDao).GetAll()
+	handler := http.HandlerFunc(getAll)
+	handler.ServeHTTP(rr, req)
+
+	// Check the status code.
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	expected := `[{"climate":"frozen","films":0,"id":"000000000000000000000000","name":"Hoth","terrain":"tundra, ice caves"}]`
+	got := rr.Body.String()
+
+	assert.Equal(t, expected, got)
+}
+
+func TestPlanetHandler_GetAll_passes_the_request_context(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/planets", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planetDao := &mocks.PlanetsDAO{}
+	deadline, _ := ctx.Deadline()
+	planetDao.
+		On("FindAll", mock.MatchedBy(func(daoCtx context.Context) bool {
+			daoDeadline, ok := daoCtx.Deadline()
+			return ok && daoDeadline == deadline
+		})).
+		Once().
+		Return([]models.Planet{}, nil)
+
+	rr := httptest.NewRecorder()
+	NewPlanetHandler(planetDao).GetAll().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	planetDao.AssertExpectations(t)
+}
+
+func TestPlanetHandler_GetAll_spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	req, err := http.NewRequest(http.MethodGet, "/api/planets", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planetDao := &mocks.PlanetsDAO{}
+	planetDao.
+		On("FindAll", mock.MatchedBy(func(ctx context.Context) bool {
+			return trace.SpanFromContext(ctx).SpanContext().IsValid()
+		})).
+		Once().
+		Return([]models.Planet{}, nil)
+
+	NewPlanetHandler(planetDao).GetAll().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "encode response", spans[0].Name())
+	assert.Equal(t, "PlanetHandler.GetAll", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	planetDao.AssertExpectations(t)
+}
+
+func Test_errorHandler_with_context_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{"deadline exceeded", context.DeadlineExceeded, http.StatusGatewayTimeout, REQUEST_TIMEOUT_ERROR_MESSAGE},
+		{"wrapped deadline", errors.New("connection(localhost:27017) failed: context deadline exceeded"), http.StatusGatewayTimeout, REQUEST_TIMEOUT_ERROR_MESSAGE},
+		{"max time expired", mongo.CommandError{Code: MAX_TIME_MS_EXPIRED_CODE, Name: "MaxTimeMSExpired"}, http.StatusGatewayTimeout, REQUEST_TIMEOUT_ERROR_MESSAGE},
+		{"client canceled", context.Canceled, STATUS_CLIENT_CLOSED_REQUEST, CLIENT_CLOSED_REQUEST_ERROR_MESSAGE},
+		{"other error", errors.New("mocked-error"), http.StatusInternalServerError, INTERNAL_SERVER_ERROR_MESSAGE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			errorHandler(rr, httptest.NewRequest(http.MethodGet, "/api/planets", nil), tt.err)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, fmt.Sprintf(`{"error":"%s"}`, tt.wantBody), rr.Body.String())
+		})
+	}
+}
+
+func Test_errorHandler_with_open_breaker(t *testing.T) {
+	rr := httptest.NewRecorder()
+	errorHandler(rr, httptest.NewRequest(http.MethodGet, "/api/planets", nil), &breaker.OpenError{RetryAfter: 2500 * time.Millisecond})
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "3", rr.Header().Get("Retry-After"))
+	assert.Equal(t, `{"error":"Service Unavailable"}`, rr.Body.String())
+}
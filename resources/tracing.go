@@ -0,0 +1,21 @@
+package resources
+
+import (
+	"net/http"
+
+	"github.com/wallacebenevides/star-wars-api/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan opens the span of a handler, the returned request carries it down to the DAO
+func startSpan(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx, span := tracing.Tracer().Start(r.Context(), name)
+	return r.WithContext(ctx), span
+}
+
+// traceResponse wraps the encoding and writing of a response in its own span
+func traceResponse(r *http.Request, respond func()) {
+	_, span := tracing.Tracer().Start(r.Context(), "encode response")
+	defer span.End()
+	respond()
+}
@@ -0,0 +1,37 @@
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+// Delay is the wait before the given retry, counted from 0: the initial delay
+// doubled on each attempt up to the max, minus a random jitter
+func Delay(b config.Backoff, attempt int) time.Duration {
+	delay := b.Initial
+	for i := 0; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	if b.Jitter > 0 && delay > 0 {
+		delay -= time.Duration(rand.Float64() * b.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Sleep waits for d or until ctx is done, returning its error
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,37 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/config"
+)
+
+func TestDelay(t *testing.T) {
+	b := config.Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, Delay(b, 0))
+	assert.Equal(t, 200*time.Millisecond, Delay(b, 1))
+	assert.Equal(t, 800*time.Millisecond, Delay(b, 3))
+	assert.Equal(t, time.Second, Delay(b, 4))
+	assert.Equal(t, time.Second, Delay(b, 100))
+}
+
+func TestDelay_with_jitter(t *testing.T) {
+	b := config.Backoff{Initial: time.Second, Max: time.Second, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := Delay(b, 0)
+		assert.True(t, delay > 500*time.Millisecond && delay <= time.Second, delay)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	assert.NoError(t, Sleep(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, Sleep(ctx, time.Hour))
+}
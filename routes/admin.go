@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/resources"
+)
+
+func adminRoutes(r *mux.Router, db db.DatabaseHelper) {
+	handler := resources.NewAPIKeyHandler(dao.NewAPIKeysDao(db))
+	r.HandleFunc("/admin/keys", auth.Require(auth.SCOPE_ADMIN, handler.GetAll())).Methods(http.MethodGet)
+	r.HandleFunc("/admin/keys", auth.Require(auth.SCOPE_ADMIN, handler.Create())).Methods(http.MethodPost)
+	r.HandleFunc("/admin/keys/{id}/rotate", auth.Require(auth.SCOPE_ADMIN, handler.Rotate())).Methods(http.MethodPost)
+	r.HandleFunc("/admin/keys/{id}", auth.Require(auth.SCOPE_ADMIN, handler.Revoke())).Methods(http.MethodDelete)
+}
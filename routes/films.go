@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/resources"
+)
+
+func filmsRoutes(r *mux.Router, db db.DatabaseHelper, planets dao.PlanetsDAO) {
+	handler := resources.NewFilmHandler(dao.NewFilmsDao(db), planets)
+	r.HandleFunc("/films", auth.Require(auth.SCOPE_FILMS_READ, handler.GetAll())).Methods(http.MethodGet)
+	r.HandleFunc("/films", auth.Require(auth.SCOPE_FILMS_WRITE, handler.Create())).Methods(http.MethodPost)
+	r.HandleFunc("/films", auth.Require(auth.SCOPE_FILMS_WRITE, handler.Delete())).Methods(http.MethodDelete)
+	r.HandleFunc("/films/{id}", auth.Require(auth.SCOPE_FILMS_READ, handler.GetByID())).Methods(http.MethodGet)
+	r.HandleFunc("/films/{id}", auth.Require(auth.SCOPE_FILMS_WRITE, handler.Update())).Methods(http.MethodPut)
+	r.HandleFunc("/films/{id}/planets", auth.Require(auth.SCOPE_PLANETS_READ, handler.Planets())).Methods(http.MethodGet)
+	r.HandleFunc("/planets/{id}/films", auth.Require(auth.SCOPE_FILMS_READ, handler.PlanetFilms())).Methods(http.MethodGet)
+	// linking a film changes the planet
+	r.HandleFunc("/planets/{id}/films/{filmId}", auth.Require(auth.SCOPE_PLANETS_WRITE, handler.LinkPlanet())).Methods(http.MethodPut)
+	r.HandleFunc("/planets/{id}/films/{filmId}", auth.Require(auth.SCOPE_PLANETS_WRITE, handler.UnlinkPlanet())).Methods(http.MethodDelete)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/resources"
+)
+
+func peopleRoutes(r *mux.Router, db db.DatabaseHelper, planets dao.PlanetsDAO) {
+	handler := resources.NewPersonHandler(dao.NewPeopleDao(db), planets)
+	r.HandleFunc("/people", auth.Require(auth.SCOPE_PEOPLE_READ, handler.GetAll())).Methods(http.MethodGet)
+	r.HandleFunc("/people", auth.Require(auth.SCOPE_PEOPLE_WRITE, handler.Create())).Methods(http.MethodPost)
+	r.HandleFunc("/people", auth.Require(auth.SCOPE_PEOPLE_WRITE, handler.Delete())).Methods(http.MethodDelete)
+	r.HandleFunc("/people/findByName", auth.Require(auth.SCOPE_PEOPLE_READ, handler.FindByName())).Methods(http.MethodGet)
+	r.HandleFunc("/people/{id}", auth.Require(auth.SCOPE_PEOPLE_READ, handler.GetByID())).Methods(http.MethodGet)
+	r.HandleFunc("/planets/{id}/residents", auth.Require(auth.SCOPE_PEOPLE_READ, handler.Residents())).Methods(http.MethodGet)
+}
@@ -4,17 +4,17 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/wallacebenevides/star-wars-api/auth"
 	"github.com/wallacebenevides/star-wars-api/dao"
-	"github.com/wallacebenevides/star-wars-api/db"
 	"github.com/wallacebenevides/star-wars-api/resources"
 )
 
-func planetsRoutes(r *mux.Router, db db.DatabaseHelper) {
-	dao := dao.NewPlanetsDao(db)
-	handler := resources.NewPlanetHandler(dao)
-	r.HandleFunc("/planets", handler.GetAll()).Methods(http.MethodGet)
-	r.HandleFunc("/planets", handler.Create()).Methods(http.MethodPost)
-	r.HandleFunc("/planets", handler.Delete()).Methods(http.MethodDelete)
-	r.HandleFunc("/planets/findByName", handler.FindByName()).Methods(http.MethodGet)
-	r.HandleFunc("/planets/{id}", handler.GetByID()).Methods(http.MethodGet)
+func planetsRoutes(r *mux.Router, planets dao.PlanetsDAO) {
+	handler := resources.NewPlanetHandler(planets)
+	r.HandleFunc("/planets", auth.Require(auth.SCOPE_PLANETS_READ, handler.GetAll())).Methods(http.MethodGet)
+	r.HandleFunc("/planets", auth.Require(auth.SCOPE_PLANETS_WRITE, handler.Create())).Methods(http.MethodPost)
+	r.HandleFunc("/planets", auth.Require(auth.SCOPE_PLANETS_WRITE, handler.Delete())).Methods(http.MethodDelete)
+	r.HandleFunc("/planets/stats", auth.Require(auth.SCOPE_PLANETS_READ, handler.Stats())).Methods(http.MethodGet)
+	r.HandleFunc("/planets/findByName", auth.Require(auth.SCOPE_PLANETS_READ, handler.FindByName())).Methods(http.MethodGet)
+	r.HandleFunc("/planets/{id}", auth.Require(auth.SCOPE_PLANETS_READ, handler.GetByID())).Methods(http.MethodGet)
 }
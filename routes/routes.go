@@ -2,8 +2,19 @@ package routes
 
 import "github.com/gorilla/mux"
 
+import "github.com/wallacebenevides/star-wars-api/breaker"
+import "github.com/wallacebenevides/star-wars-api/config"
+import "github.com/wallacebenevides/star-wars-api/dao"
 import "github.com/wallacebenevides/star-wars-api/db"
 
-func Routes(router *mux.Router, db db.DatabaseHelper) {
-	planetsRoutes(router, db)
+func Routes(router *mux.Router, db db.DatabaseHelper, planetsBreaker *breaker.Breaker, retry config.Retry) {
+	// the planets DAO is shared by every resource so its metrics and its
+	// circuit breaker cover all the routes, the breaker only counts the
+	// calls that failed after the retries
+	planets := dao.NewInstrumentedPlanetsDao(
+		dao.NewBreakerPlanetsDao(dao.NewRetryPlanetsDao(dao.NewPlanetsDao(db), retry), planetsBreaker))
+	planetsRoutes(router, planets)
+	filmsRoutes(router, db, planets)
+	peopleRoutes(router, db, planets)
+	adminRoutes(router, db)
 }
@@ -0,0 +1,225 @@
+package routes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/breaker"
+	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/middleware"
+	"github.com/wallacebenevides/star-wars-api/migrations"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"github.com/wallacebenevides/star-wars-api/seed"
+)
+
+// newTestRouter serves the whole api from a migrated and seeded in-memory database
+func newTestRouter(t *testing.T) *mux.Router {
+	r, _ := newAuthTestRouter(t, func(db.DatabaseHelper) mux.MiddlewareFunc { return middleware.Anonymous })
+	return r
+}
+
+// newAuthTestRouter authenticates the requests with the middleware of the
+// database, it also returns the database to create the keys in
+func newAuthTestRouter(t *testing.T, authentication func(db.DatabaseHelper) mux.MiddlewareFunc) (*mux.Router, db.DatabaseHelper) {
+	database := db.NewMemoryClient().Database("star_wars_test")
+	_, err := migrations.NewMigrator(database, migrations.All()).Up(context.Background())
+	assert.NoError(t, err)
+	_, err = seed.NewSeeder(dao.NewPlanetsDao(database), dao.NewFilmsDao(database)).Seed(context.Background(), seed.PROFILE_TEST)
+	assert.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.Use(authentication(database))
+	Routes(r, database, breaker.New(dao.COLLECTION, config.Breaker{
+		Enabled: true, Window: 30 * time.Second, MinRequests: 10, FailureRate: 0.5, OpenTimeout: 10 * time.Second, HalfOpenRequests: 1,
+	}), config.Retry{Attempts: 3, Backoff: config.Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}})
+	return r, database
+}
+
+func serve(r *mux.Router, method, path, body string, v interface{}) int {
+	return serveWithKey(r, "", method, path, body, v)
+}
+
+func serveWithKey(r *mux.Router, key, method, path, body string, v interface{}) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if v != nil {
+		json.Unmarshal(rr.Body.Bytes(), v)
+	}
+	return rr.Code
+}
+
+func TestRoutes_planets(t *testing.T) {
+	r := newTestRouter(t)
+
+	var planets []models.Planet
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets", "", &planets))
+	assert.Len(t, planets, 3)
+
+	var found []models.Planet
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets/findByName?name=arid", "", &found))
+	assert.Len(t, found, 1)
+	assert.Equal(t, models.StringList{"desert", "mountains"}, found[0].Terrain)
+	assert.Equal(t, 2, found[0].Films)
+
+	var planet models.Planet
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets/"+found[0].ID.Hex(), "", &planet))
+	assert.Equal(t, found[0].Name, planet.Name)
+
+	assert.Equal(t, http.StatusCreated, serve(r, http.MethodPost, "/planets", `{"name":"Hoth","climate":["frozen"],"terrain":["tundra"]}`, nil))
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets/findByName?name=hoth", "", &found))
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodDelete, "/planets", `{"id":"`+found[0].ID.Hex()+`"}`, nil))
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/planets/findByName?name=hoth", "", nil))
+}
+
+func TestRoutes_planets_stats(t *testing.T) {
+	r := newTestRouter(t)
+
+	var stats models.PlanetStats
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets/stats?groupBy=terrain&minFilms=1", "", &stats))
+
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 1, stats.Films.Min)
+	assert.Equal(t, 2, stats.Films.Max)
+	assert.Len(t, stats.Groups, 3)
+}
+
+func TestRoutes_films_and_residents(t *testing.T) {
+	r := newTestRouter(t)
+
+	var planets []models.Planet
+	serve(r, http.MethodGet, "/planets/findByName?name=empty", "", &planets)
+	assert.Len(t, planets, 1)
+	planetID := planets[0].ID.Hex()
+
+	var films []models.Film
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/films", "", &films))
+	assert.Len(t, films, 2)
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodPut, "/planets/"+planetID+"/films/"+films[0].ID.Hex(), "", nil))
+	var planetFilms []models.Film
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets/"+planetID+"/films", "", &planetFilms))
+	assert.Len(t, planetFilms, 1)
+
+	person := `{"name":"Test Resident","homeworld":"` + planetID + `"}`
+	assert.Equal(t, http.StatusCreated, serve(r, http.MethodPost, "/people", person, nil))
+
+	var expanded models.ExpandedPlanet
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets/"+planetID+"?expand=residents", "", &expanded))
+	assert.Equal(t, 1, expanded.Films)
+	assert.Len(t, expanded.Residents, 1)
+	assert.Equal(t, "Test Resident", expanded.Residents[0].Name)
+}
+
+func TestRoutes_api_keys(t *testing.T) {
+	r, database := newAuthTestRouter(t, func(database db.DatabaseHelper) mux.MiddlewareFunc {
+		return middleware.Authenticate(auth.NewAPIKeyAuthenticator(dao.NewAPIKeysDao(database)))
+	})
+	admin, adminKey, err := auth.NewAPIKey("admin", []string{auth.SCOPE_ADMIN})
+	assert.NoError(t, err)
+	assert.NoError(t, dao.NewAPIKeysDao(database).Create(context.Background(), admin))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(r, http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(r, "swapi_unknown", http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusForbidden, serveWithKey(r, adminKey, http.MethodGet, "/planets", "", nil))
+
+	var created struct {
+		ID     string
+		Key    string
+		Prefix string
+		Hash   string
+	}
+	assert.Equal(t, http.StatusCreated, serveWithKey(r, adminKey, http.MethodPost, "/admin/keys",
+		`{"name":"reader","scopes":["planets:read"]}`, &created))
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+	assert.Empty(t, created.Hash)
+	assert.Equal(t, http.StatusBadRequest, serveWithKey(r, adminKey, http.MethodPost, "/admin/keys",
+		`{"name":"reader","scopes":["planets:everything"]}`, nil))
+
+	assert.Equal(t, http.StatusOK, serveWithKey(r, created.Key, http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusForbidden, serveWithKey(r, created.Key, http.MethodPost, "/planets", `{"name":"Hoth"}`, nil))
+	assert.Equal(t, http.StatusForbidden, serveWithKey(r, created.Key, http.MethodGet, "/admin/keys", "", nil))
+
+	var listed []map[string]interface{}
+	assert.Equal(t, http.StatusOK, serveWithKey(r, adminKey, http.MethodGet, "/admin/keys", "", &listed))
+	assert.Len(t, listed, 2)
+	assert.NotContains(t, listed[1], "key")
+
+	var rotated struct{ Key string }
+	assert.Equal(t, http.StatusOK, serveWithKey(r, adminKey, http.MethodPost, "/admin/keys/"+created.ID+"/rotate", "", &rotated))
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(r, created.Key, http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusOK, serveWithKey(r, rotated.Key, http.MethodGet, "/planets", "", nil))
+
+	assert.Equal(t, http.StatusOK, serveWithKey(r, adminKey, http.MethodDelete, "/admin/keys/"+created.ID, "", nil))
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(r, rotated.Key, http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusNotFound, serveWithKey(r, adminKey, http.MethodDelete, "/admin/keys/"+created.ID, "", nil))
+}
+
+func TestRoutes_admin_without_auth(t *testing.T) {
+	r := newTestRouter(t)
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodPost, "/admin/keys", `{"name":"ops","scopes":["admin"]}`, nil))
+	assert.Equal(t, http.StatusForbidden, serve(r, http.MethodGet, "/admin/keys", "", nil))
+}
+
+func TestRoutes_jwt_roles(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "1", "kty": "EC", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+			"y": base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+		}}})
+	}))
+	defer jwks.Close()
+	cnf := config.JWT{
+		JWKSURL: jwks.URL, Issuer: "https://idp.example.com", Audience: "star-wars-api",
+		Algorithms: config.JWT_ALGORITHMS, RolesClaim: "roles", CacheTTL: time.Hour,
+		Roles: map[string][]string{
+			"viewer": {auth.SCOPE_PLANETS_READ},
+			"editor": {auth.SCOPE_PLANETS_READ, auth.SCOPE_PLANETS_WRITE},
+		},
+	}
+	r, _ := newAuthTestRouter(t, func(database db.DatabaseHelper) mux.MiddlewareFunc {
+		authenticator, err := auth.NewJWTAuthenticator(cnf, auth.NewJWKS(cnf.JWKSURL, cnf.CacheTTL, 0))
+		assert.NoError(t, err)
+		return middleware.Authenticate(auth.NewAPIKeyAuthenticator(dao.NewAPIKeysDao(database)), authenticator)
+	})
+	token := func(role string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"sub": "luke", "iss": cnf.Issuer, "aud": cnf.Audience, "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{role},
+		})
+		token.Header["kid"] = "1"
+		signed, _ := token.SignedString(key)
+		return signed
+	}
+	viewer, editor := token("viewer"), token("editor")
+
+	assert.Equal(t, http.StatusOK, serveWithKey(r, viewer, http.MethodGet, "/planets", "", nil))
+	assert.Equal(t, http.StatusForbidden, serveWithKey(r, viewer, http.MethodPost, "/planets", `{"name":"Hoth"}`, nil))
+	assert.Equal(t, http.StatusCreated, serveWithKey(r, editor, http.MethodPost, "/planets", `{"name":"Hoth"}`, nil))
+	var found []models.Planet
+	assert.Equal(t, http.StatusOK, serveWithKey(r, viewer, http.MethodGet, "/planets/findByName?name=hoth", "", &found))
+	assert.Equal(t, http.StatusForbidden, serveWithKey(r, viewer, http.MethodDelete, "/planets", `{"id":"`+found[0].ID.Hex()+`"}`, nil))
+	assert.Equal(t, http.StatusOK, serveWithKey(r, editor, http.MethodDelete, "/planets", `{"id":"`+found[0].ID.Hex()+`"}`, nil))
+	assert.Equal(t, http.StatusUnauthorized, serveWithKey(r, editor+"x", http.MethodGet, "/planets", "", nil))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/seed"
+)
+
+var (
+	seedOnStartup = flag.Bool("seed", false, "upsert the embedded seed dataset before serving")
+	seedProfile   = flag.String("seed-profile", seed.PROFILE_FULL, "seed dataset profile: minimal, full or test")
+)
+
+// seedDatabase upserts the planets and films of a seed profile
+func seedDatabase(ctx context.Context, database db.DatabaseHelper, profile string) error {
+	seeder := seed.NewSeeder(dao.NewPlanetsDao(database), dao.NewFilmsDao(database))
+	_, err := seeder.Seed(ctx, profile)
+	return err
+}
+
+// runSeedCommand handles "seed [profile]"
+func runSeedCommand(database db.DatabaseHelper, args []string) {
+	profile := *seedProfile
+	if len(args) > 0 {
+		profile = args[0]
+	}
+	if err := seedDatabase(context.Background(), database, profile); err != nil {
+		log.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package seed
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/dao"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PROFILE_MINIMAL = "minimal"
+	PROFILE_FULL    = "full"
+	PROFILE_TEST    = "test"
+)
+
+//go:embed data/*.json
+var datasets embed.FS
+
+// Dataset is the content of a seed profile, planets reference their films by episode
+type Dataset struct {
+	Films   []models.Film `json:"films"`
+	Planets []PlanetSeed  `json:"planets"`
+}
+
+type PlanetSeed struct {
+	Name     string            `json:"name"`
+	Climate  models.StringList `json:"climate"`
+	Terrain  models.StringList `json:"terrain"`
+	Episodes []int             `json:"episodes"`
+}
+
+// Result counts the seeded documents
+type Result struct {
+	Films   int `json:"films"`
+	Planets int `json:"planets"`
+}
+
+// Profiles lists the available dataset profiles
+func Profiles() []string {
+	return []string{PROFILE_MINIMAL, PROFILE_FULL, PROFILE_TEST}
+}
+
+// Load reads the embedded dataset of a profile
+func Load(profile string) (*Dataset, error) {
+	data, err := datasets.ReadFile("data/" + profile + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown seed profile %q, use one of %v", profile, Profiles())
+	}
+	var dataset Dataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("invalid seed profile %q: %v", profile, err)
+	}
+	return &dataset, nil
+}
+
+// Seeder upserts a dataset, so seeding the same profile twice doesn't duplicate documents
+type Seeder struct {
+	planets dao.PlanetsDAO
+	films   dao.FilmsDAO
+}
+
+func NewSeeder(planets dao.PlanetsDAO, films dao.FilmsDAO) *Seeder {
+	return &Seeder{planets: planets, films: films}
+}
+
+func (s *Seeder) Seed(ctx context.Context, profile string) (*Result, error) {
+	dataset, err := Load(profile)
+	if err != nil {
+		return nil, err
+	}
+	episodes := map[int]primitive.ObjectID{}
+	for i := range dataset.Films {
+		film := dataset.Films[i]
+		if err := s.films.UpsertByEpisode(ctx, &film); err != nil {
+			return nil, err
+		}
+		episodes[film.EpisodeID] = film.ID
+	}
+	for _, seed := range dataset.Planets {
+		planet := models.Planet{Name: seed.Name, Climate: seed.Climate, Terrain: seed.Terrain, FilmIDs: []primitive.ObjectID{}}
+		for _, episode := range seed.Episodes {
+			filmID, ok := episodes[episode]
+			if !ok {
+				return nil, fmt.Errorf("planet %q references the unknown episode %d", seed.Name, episode)
+			}
+			planet.FilmIDs = append(planet.FilmIDs, filmID)
+		}
+		if err := s.planets.UpsertByName(ctx, &planet); err != nil {
+			return nil, err
+		}
+	}
+	result := &Result{Films: len(dataset.Films), Planets: len(dataset.Planets)}
+	log.WithField("profile", profile).Info("Seeded ", result.Films, " films and ", result.Planets, " planets")
+	return result, nil
+}
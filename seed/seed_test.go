@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wallacebenevides/star-wars-api/mocks"
+	"github.com/wallacebenevides/star-wars-api/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLoad_every_profile(t *testing.T) {
+	for _, profile := range Profiles() {
+		dataset, err := Load(profile)
+		assert.NoError(t, err, profile)
+
+		episodes := map[int]bool{}
+		for _, film := range dataset.Films {
+			episodes[film.EpisodeID] = true
+		}
+		names := map[string]bool{}
+		for _, planet := range dataset.Planets {
+			assert.False(t, names[planet.Name], "duplicated planet %s in %s", planet.Name, profile)
+			names[planet.Name] = true
+			for _, episode := range planet.Episodes {
+				assert.True(t, episodes[episode], "unknown episode %d of %s in %s", episode, planet.Name, profile)
+			}
+		}
+	}
+}
+
+func TestLoad_with_unknown_profile(t *testing.T) {
+	dataset, err := Load("huge")
+
+	assert.Nil(t, dataset)
+	assert.Error(t, err)
+}
+
+func TestSeeder_Seed(t *testing.T) {
+	filmDao := &mocks.FilmsDAO{}
+	planetDao := &mocks.PlanetsDAO{}
+
+	filmIDs := map[int]primitive.ObjectID{}
+	filmDao.
+		On("UpsertByEpisode", context.Background(), mock.AnythingOfType("*models.Film")).
+		Run(func(args mock.Arguments) {
+			film := args.Get(1).(*models.Film)
+			film.ID = primitive.NewObjectID()
+			filmIDs[film.EpisodeID] = film.ID
+		}).
+		Return(nil)
+
+	var planets []models.Planet
+	planetDao.
+		On("UpsertByName", context.Background(), mock.AnythingOfType("*models.Planet")).
+		Run(func(args mock.Arguments) {
+			planets = append(planets, *args.Get(1).(*models.Planet))
+		}).
+		Return(nil)
+
+	result, err := NewSeeder(planetDao, filmDao).Seed(context.Background(), PROFILE_TEST)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Films: 2, Planets: 3}, result)
+	assert.Equal(t, "Test Planet Arid", planets[0].Name)
+	assert.Equal(t, models.StringList{"desert", "mountains"}, planets[0].Terrain)
+	assert.Equal(t, []primitive.ObjectID{filmIDs[1], filmIDs[2]}, planets[0].FilmIDs)
+	assert.Empty(t, planets[2].FilmIDs)
+}
+
+func TestSeeder_Seed_with_error(t *testing.T) {
+	filmDao := &mocks.FilmsDAO{}
+	filmDao.
+		On("UpsertByEpisode", context.Background(), mock.Anything).
+		Once().
+		Return(errors.New("mocked-error"))
+
+	result, err := NewSeeder(&mocks.PlanetsDAO{}, filmDao).Seed(context.Background(), PROFILE_TEST)
+
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "mocked-error")
+}
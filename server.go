@@ -1,34 +1,157 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
+	"github.com/wallacebenevides/star-wars-api/auth"
+	"github.com/wallacebenevides/star-wars-api/breaker"
 	"github.com/wallacebenevides/star-wars-api/config"
+	"github.com/wallacebenevides/star-wars-api/dao"
 	"github.com/wallacebenevides/star-wars-api/db"
+	"github.com/wallacebenevides/star-wars-api/features"
+	"github.com/wallacebenevides/star-wars-api/health"
+	"github.com/wallacebenevides/star-wars-api/logging"
+	"github.com/wallacebenevides/star-wars-api/metrics"
+	"github.com/wallacebenevides/star-wars-api/middleware"
 	"github.com/wallacebenevides/star-wars-api/routes"
+	"github.com/wallacebenevides/star-wars-api/tracing"
 )
 
 func main() {
+	flag.Parse()
 
 	log.SetFormatter(&log.TextFormatter{
 		FullTimestamp: true,
 	})
 
-	config := config.Config{}
-	config.Read()
-	database := initializeDB(config)
+	loader := config.NewLoader(*configFile)
+	config, err := loader.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(config.Logging); err != nil {
+		log.Fatal(err)
+	}
+	if flag.Arg(0) == "config" {
+		runConfigCommand(config, flag.Args()[1:])
+		return
+	}
+	if file := loader.File(); file != "" {
+		log.Info("Read the config file ", file)
+	} else {
+		log.Info("No config file found, using the defaults and the environment")
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	onShutdown("tracing", shutdownTracing)
+	database, availability := initializeDB(config, flag.Arg(0) == "")
+	onShutdown("database", database.Client().Disconnect)
+
+	switch flag.Arg(0) {
+	case "migrate":
+		runMigrateCommand(database, flag.Args()[1:])
+		runShutdownHooks(context.Background())
+		return
+	case "seed":
+		runSeedCommand(database, flag.Args()[1:])
+		runShutdownHooks(context.Background())
+		return
+	case "apikeys":
+		runAPIKeysCommand(database, flag.Args()[1:])
+		runShutdownHooks(context.Background())
+		return
+	}
+	prepareDB := func(ctx context.Context) error {
+		if config.Migrations.Auto {
+			if err := migrate(ctx, database); err != nil {
+				return err
+			}
+		}
+		if *seedOnStartup {
+			return seedDatabase(ctx, database, *seedProfile)
+		}
+		return nil
+	}
+	if availability.Available() {
+		if err := prepareDB(context.Background()); err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		availability.OnRecover(prepareDB)
+		ctx, cancel := context.WithCancel(context.Background())
+		onDrain(cancel)
+		go availability.Recover(ctx)
+	}
 
 	r := mux.NewRouter()
+	checker := health.NewChecker()
+	checker.Register("database", availability.Check)
+	planetsBreaker := breaker.New(dao.COLLECTION, config.Database.Breaker)
+	checker.Register("planets_breaker", planetsBreaker.Check)
+	onDrain(checker.Shutdown)
+	healthRoutes(r, checker)
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 	api := newRouterAPI(r)
 
-	api.Use(loggingMiddleware)
-	routes.Routes(api, database)
+	api.Use(middleware.Tracing)
+	api.Use(middleware.Metrics)
+	api.Use(middleware.Logging)
+	api.Use(middleware.Available(availability.Available, db.UNAVAILABLE_ERROR_MESSAGE))
+	timeouts := middleware.NewTimeouts(config.Server.Timeouts)
+	api.Use(timeouts.Middleware)
+	rateLimit := middleware.NewRateLimit(config.RateLimit)
+	api.Use(rateLimit.Unauthenticated)
+	if config.Auth.Enabled {
+		api.Use(middleware.Authenticate(authenticators(config.Auth, database)...))
+	} else {
+		log.Warn("The authentication is disabled, every request may use every route but the admin ones")
+		api.Use(middleware.Anonymous)
+	}
+	api.Use(rateLimit.Middleware)
+	flags := features.New(config.Features)
+	api.Use(flags.Middleware)
+	stopEvicting := make(chan struct{})
+	onDrain(func() { close(stopEvicting) })
+	go rateLimit.Run(stopEvicting)
+	routes.Routes(api, database, planetsBreaker, config.Database.Retry)
+	cors := middleware.NewCORS(config.CORS)
+	watchConfig(loader, config, timeouts, rateLimit, cors, flags)
 
-	log.Info("star wars planets api is listening on port ", config.Server.Port)
-	log.Fatal(http.ListenAndServe(":"+config.Server.Port, r))
+	srv, err := newServer(config.Server, cors.Handler(r))
+	if err != nil {
+		log.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if config.Server.TLS.Enabled && config.Server.TLS.RedirectPort != "" {
+		if err := serveRedirect(config.Server); err != nil {
+			log.Fatal(err)
+		}
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	scheme := "http"
+	if srv.TLSConfig != nil {
+		scheme = "https"
+	}
+	log.Info("star wars planets api is listening for ", scheme, " on port ", config.Server.Port)
+	if err := serve(srv, listener, config.Server.GracePeriod, stop); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
 }
 
 func newRouterAPI(r *mux.Router) *mux.Router {
@@ -39,19 +162,60 @@ func newRouterAPI(r *mux.Router) *mux.Router {
 	return api
 }
 
-func initializeDB(config config.Config) db.DatabaseHelper {
+// healthRoutes are kept out of /api so the probes skip its middlewares
+func healthRoutes(r *mux.Router, checker *health.Checker) {
+	r.HandleFunc("/healthz", checker.Liveness()).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", checker.Readiness()).Methods(http.MethodGet)
+}
+
+// initializeDB opens the database of the config, a mongo database that
+// doesn't answer after the startup attempts is fatal unless the server may
+// start degraded
+func initializeDB(cnf config.Config, mayDegrade bool) (db.DatabaseHelper, *db.Availability) {
+	switch cnf.Database.Driver {
+	case config.DRIVER_MEMORY:
+		log.Warn("Using the in-memory database, data is lost when the server stops")
+		client := db.NewMemoryClient()
+		return db.NewDatabase(&cnf.Database, client), db.NewAvailability(client, cnf.Database.Startup.Backoff, true)
+	case config.DRIVER_BOLT:
+		client, err := db.NewBoltClient(cnf.Database.Path)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Info("Opened the database file ", cnf.Database.Path)
+		return db.NewDatabase(&cnf.Database, client), db.NewAvailability(client, cnf.Database.Startup.Backoff, true)
+	}
 	// initialize db config
-	client, err := db.NewClient(&config.Database)
+	client, err := db.NewClient(&cnf.Database)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
-	log.Info("Connected to MongoDB!")
-	return db.NewDatabase(&config.Database, client)
+	available := true
+	if err := db.WaitFor(context.Background(), client, cnf.Database.Startup); err != nil {
+		if !mayDegrade || !cnf.Database.Startup.Degraded {
+			log.Fatal(err.Error())
+		}
+		log.WithError(err).Warn("Serving in degraded mode until MongoDB answers")
+		available = false
+	} else {
+		log.Info("Connected to MongoDB!")
+	}
+	return db.NewDatabase(&cnf.Database, client), db.NewAvailability(client, cnf.Database.Startup.Backoff, available)
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Info(r.RequestURI)
-		next.ServeHTTP(w, r)
-	})
+// authenticators accept the API keys and, when configured, the JWTs
+func authenticators(cnf config.Auth, database db.DatabaseHelper) []auth.Authenticator {
+	authenticators := []auth.Authenticator{auth.NewAPIKeyAuthenticator(dao.NewAPIKeysDao(database))}
+	if !cnf.JWT.Enabled {
+		return authenticators
+	}
+	keys := auth.NewJWKS(cnf.JWT.JWKSURL, cnf.JWT.CacheTTL, cnf.JWT.MinRefreshInterval)
+	if err := keys.Refresh(); err != nil {
+		log.WithError(err).Warn("The tokens can't be verified until the jwks is fetched")
+	}
+	authenticator, err := auth.NewJWTAuthenticator(cnf.JWT, keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return append(authenticators, authenticator)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DEFAULT_GRACE_PERIOD = 15 * time.Second
+)
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var shutdownHooks []shutdownHook
+
+// drainHooks run as soon as a stop signal arrives, before the requests are drained
+var drainHooks []func()
+
+func onDrain(fn func()) {
+	drainHooks = append(drainHooks, fn)
+}
+
+// onShutdown registers a function to flush or release something once the
+// server stopped, hooks run in reverse order like deferred calls
+func onShutdown(name string, fn func(ctx context.Context) error) {
+	shutdownHooks = append(shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+func runShutdownHooks(ctx context.Context) {
+	for i := len(shutdownHooks) - 1; i >= 0; i-- {
+		hook := shutdownHooks[i]
+		if err := hook.fn(ctx); err != nil {
+			log.WithField("hook", hook.name).Error("There was an error shutting down::", err.Error())
+			continue
+		}
+		log.WithField("hook", hook.name).Debug("Shut down")
+	}
+	shutdownHooks = nil
+}
+
+// serve accepts connections, over tls when srv has a TLSConfig, until a signal
+// arrives on stop, then stops listening, waits up to gracePeriod for the
+// in-flight requests and runs the shutdown hooks. The requests still running
+// after the grace period are cut with a warning, the stop isn't an error.
+func serve(srv *http.Server, listener net.Listener, gracePeriod time.Duration, stop <-chan os.Signal) error {
+	if gracePeriod <= 0 {
+		gracePeriod = DEFAULT_GRACE_PERIOD
+	}
+	errs := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errs <- srv.ServeTLS(listener, "", "")
+			return
+		}
+		errs <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-errs:
+		runShutdownHooks(context.Background())
+		return err
+	case sig := <-stop:
+		log.Info("Received ", sig, ", draining requests for up to ", gracePeriod)
+	}
+	for _, drain := range drainHooks {
+		drain()
+	}
+	drainHooks = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.WithError(err).Warn("The grace period is over, closing the remaining connections")
+		srv.Close()
+	}
+
+	hooksCtx, cancelHooks := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancelHooks()
+	runShutdownHooks(hooksCtx)
+	log.Info("star wars planets api stopped")
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startServer serves handler on a random port, returning its url and the channels
+// to stop it and to wait for serve to return
+func startServer(t *testing.T, handler http.HandlerFunc, gracePeriod time.Duration) (string, chan os.Signal, chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(&http.Server{Handler: handler}, listener, gracePeriod, stop)
+	}()
+	return "http://" + listener.Addr().String(), stop, done
+}
+
+func Test_serve_drains_requests_between_the_hooks(t *testing.T) {
+	events := make(chan string, 3)
+	onDrain(func() {
+		events <- "draining"
+	})
+	onShutdown("database", func(ctx context.Context) error {
+		events <- "disconnected"
+		return nil
+	})
+	started, release := make(chan bool), make(chan bool)
+	url, stop, done := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-release
+		events <- "request finished"
+	}, time.Second)
+
+	responses := make(chan int)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	<-started
+	stop <- syscall.SIGTERM
+	time.Sleep(50 * time.Millisecond)
+	release <- true
+
+	assert.Equal(t, http.StatusOK, <-responses)
+	assert.NoError(t, <-done)
+	assert.Equal(t, "draining", <-events)
+	assert.Equal(t, "request finished", <-events)
+	assert.Equal(t, "disconnected", <-events)
+	_, err := http.Get(url)
+	assert.Error(t, err)
+}
+
+func Test_serve_with_grace_period_exceeded(t *testing.T) {
+	hooked := false
+	onShutdown("worker", func(ctx context.Context) error {
+		hooked = true
+		return nil
+	})
+	started, release := make(chan bool), make(chan bool)
+	defer close(release)
+	url, stop, done := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-release
+	}, 50*time.Millisecond)
+
+	go http.Get(url)
+	<-started
+	stop <- syscall.SIGINT
+
+	assert.NoError(t, <-done, "the server stops normally")
+	assert.True(t, hooked)
+}
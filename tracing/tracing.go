@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wallacebenevides/star-wars-api/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TRACER_NAME          = "github.com/wallacebenevides/star-wars-api"
+	DEFAULT_SERVICE_NAME = "star-wars-api"
+)
+
+const (
+	EXPORTER_NONE   = "none"
+	EXPORTER_STDOUT = "stdout"
+	EXPORTER_OTLP   = "otlp"
+)
+
+// Tracer returns the tracer of the api from the global provider, so it's a no-op
+// until Setup (or a test) installs a provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TRACER_NAME)
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator.
+// The returned function flushes the pending spans and stops the exporter.
+func Setup(ctx context.Context, cnf config.Tracing) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cnf.Exporter {
+	case "", EXPORTER_NONE:
+		return func(ctx context.Context) error { return nil }, nil
+	case EXPORTER_STDOUT:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case EXPORTER_OTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cnf.Endpoint)}
+		if cnf.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cnf.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := cnf.ServiceName
+	if serviceName == "" {
+		serviceName = DEFAULT_SERVICE_NAME
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceNameKey.String(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio(cnf)))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// sampleRatio samples every trace unless a ratio is configured
+func sampleRatio(cnf config.Tracing) float64 {
+	if cnf.SampleRatio <= 0 {
+		return 1
+	}
+	return cnf.SampleRatio
+}